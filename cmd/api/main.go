@@ -72,13 +72,25 @@ func main() {
 		log.Fatalf("rabbitmq setup error: %s", err)
 	}
 
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.RecoveryCode{}, &models.PasswordHistory{}, &models.Session{}, &models.UserRole{}); err != nil {
 		log.Fatalf("models migration err: %s", err)
 	}
 
 	//User usecase, repository and controller
 	userRepo := repository.NewUserRepository(db, redis)
-	userUseCase, err := usecase.NewUserUseCase(userRepo, conf, br, logger)
+	authRepo := repository.NewAuthRepository(redis)
+
+	var sessionRepo repository.SessionRepository
+	switch conf.Server.SessionStore {
+	case config.SessionStoreRedis:
+		sessionRepo = repository.NewRedisSessionRepository(redis)
+	case config.SessionStoreSQL:
+		sessionRepo = repository.NewSQLSessionRepository(db)
+	default:
+		log.Fatalf("unknown session store %q", conf.Server.SessionStore)
+	}
+
+	userUseCase, err := usecase.NewUserUseCase(userRepo, authRepo, sessionRepo, conf, br, logger)
 	if err != nil {
 		log.Fatalf("cannot initialize UserUseCase type: %s", err)
 	}
@@ -86,7 +98,10 @@ func main() {
 	userController := api.NewUserController(userUseCase)
 
 	gin.SetMode(conf.Server.Mode)
-	r := router.NewUserRouter(userController, conf)
+	r, err := router.NewUserRouter(userController, conf, redis, logger)
+	if err != nil {
+		log.Fatalf("router setup error: %s", err)
+	}
 
 	if err := r.Run(fmt.Sprintf(":%d", conf.Server.Port)); err != nil {
 		log.Fatalf("cannot start GIN server: %s", err)
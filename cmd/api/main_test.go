@@ -6,14 +6,14 @@ import (
 	"testing"
 
 	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/Hickar/gin-rush/internal/dbtest"
 	"github.com/Hickar/gin-rush/internal/router"
-	"github.com/Hickar/gin-rush/pkg/database"
 )
 
 func TestServer(t *testing.T) {
 	conf := config.NewConfig("../../conf/config.test.json")
 	router := router.NewUserRouter(&conf.Server)
-	database.NewMockDB()
+	dbtest.NewMockDB(t)
 
 	w := httptest.NewRecorder()
 	req, err := http.NewRequest("GET", "/", nil)
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Hickar/gin-rush/pkg/breach"
+)
+
+// Builds compact bloom filter from directory of HIBP range files, which can
+// be used instead of the directory itself as breached passwords corpus.
+func main() {
+	dir := flag.String("ranges", "", "directory with HIBP range files")
+	out := flag.String("out", "breached.bloom", "path of bloom filter file")
+	rate := flag.Float64("fp-rate", 0.001, "false positive rate")
+	flag.Parse()
+
+	if *dir == "" {
+		log.Fatal("directory with range files is required")
+	}
+
+	var count uint64
+	err := breach.RangeFiles(*dir, func(string) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("unable to read range files: %s", err)
+	}
+
+	filter := breach.NewBloomFilter(count, *rate)
+	err = breach.RangeFiles(*dir, func(hash string) error {
+		digest, err := breach.ParseHash(hash)
+		if err != nil {
+			return err
+		}
+
+		filter.Add(digest)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("unable to read range files: %s", err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("unable to create filter file: %s", err)
+	}
+
+	w := bufio.NewWriter(file)
+	if _, err := filter.WriteTo(w); err != nil {
+		log.Fatalf("unable to write filter: %s", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatalf("unable to write filter: %s", err)
+	}
+
+	if err := file.Close(); err != nil {
+		log.Fatalf("unable to write filter: %s", err)
+	}
+
+	log.Printf("bloom filter with %d hashes written to %s", count, *out)
+}
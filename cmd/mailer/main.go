@@ -2,12 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/Hickar/gin-rush/internal/broker"
 	"github.com/Hickar/gin-rush/internal/config"
 	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/streadway/amqp"
 )
 
 func main() {
@@ -33,7 +35,7 @@ func main() {
 		}
 	}(conn)
 
-	messages, err := conn.Consume("mailer_ex", "topic", "mailer")
+	messages, err := conn.Consume(mailer.Exchange, "topic", mailer.KeysPattern)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -42,20 +44,83 @@ func main() {
 
 	go func() {
 		for d := range messages {
-			var msg mailer.ConfirmationMessage
-
-			err := json.Unmarshal(d.Body, &msg)
-			if err != nil {
-				log.Fatalf("unable to decode queue message: %s", err)
-			}
-
-			err = mailClient.SendConfirmationCode(msg.Username, msg.Email, msg.Code)
-			if err != nil {
-				log.Fatalf("unable to send confirmation message: %s", err)
+			if err := handleMessage(mailClient, d); err != nil {
+				log.Fatalf("unable to send message: %s", err)
 			}
 		}
 	}()
 
 	<-done
 	log.Println("Waiting for new messages...")
-}
\ No newline at end of file
+}
+
+func handleMessage(mailClient *mailer.Mailer, d amqp.Delivery) error {
+	switch d.RoutingKey {
+	case mailer.ConfirmationKey:
+		var msg mailer.ConfirmationMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendConfirmationCode(msg.Username, msg.Email, msg.Code, msg.Hours)
+	case mailer.OneTimeCodeKey:
+		var msg mailer.OneTimeCodeMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendOneTimeCode(msg.Username, msg.Email, msg.Code)
+	case mailer.MagicLinkKey:
+		var msg mailer.MagicLinkMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendMagicLink(msg.Username, msg.Email, msg.Token)
+	case mailer.PasswordResetKey:
+		var msg mailer.PasswordResetMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendPasswordResetLink(msg.Username, msg.Email, msg.Token)
+	case mailer.PasswordChangedKey:
+		var msg mailer.PasswordChangedMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendPasswordChangedNotice(msg.Username, msg.Email)
+	case mailer.EmailChangeKey:
+		var msg mailer.EmailChangeMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendEmailChangeLink(msg.Username, msg.Email, msg.Token)
+	case mailer.EmailChangedKey:
+		var msg mailer.EmailChangedMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendEmailChangedNotice(msg.Username, msg.Email, msg.NewEmail)
+	case mailer.AccountLockedKey:
+		var msg mailer.AccountLockedMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendAccountLockedNotice(msg.Username, msg.Email, msg.Minutes)
+	case mailer.AccountSuspendedKey:
+		var msg mailer.AccountSuspendedMessage
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return fmt.Errorf("unable to decode queue message: %w", err)
+		}
+
+		return mailClient.SendAccountSuspendedNotice(msg.Username, msg.Email, msg.Reason, msg.Until)
+	default:
+		log.Printf("skipping message with unknown routing key %q", d.RoutingKey)
+		return nil
+	}
+}
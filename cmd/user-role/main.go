@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/Hickar/gin-rush/internal/cache"
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/database"
+)
+
+// Grants role to user or revokes it, which is needed at least to appoint the
+// first administrator. Permissions are carried by access tokens, so granted
+// role is applied on the next token refresh, while revoked one also
+// invalidates access tokens already issued to the user.
+func main() {
+	confPath := flag.String("config", "", "path of configuration file")
+	email := flag.String("email", "", "email of user")
+	role := flag.String("role", models.RoleAdmin, "name of role")
+	revoke := flag.Bool("revoke", false, "revoke role instead of granting it")
+	flag.Parse()
+
+	if *confPath == "" || *email == "" {
+		log.Fatal("configuration file and email are required")
+	}
+
+	conf := config.NewConfig(*confPath)
+
+	roles := models.DefaultRolePermissions
+	if conf.Roles != nil {
+		roles = conf.Roles
+	}
+
+	if _, ok := roles[*role]; !ok && !*revoke {
+		log.Fatalf("unknown role %q", *role)
+	}
+
+	db, err := database.NewDatabase(&conf.Database, nil)
+	if err != nil {
+		log.Fatalf("database setup error: %s", err)
+	}
+
+	if err := db.AutoMigrate(&models.UserRole{}); err != nil {
+		log.Fatalf("models migration err: %s", err)
+	}
+
+	// Users aren't updated, so their cache isn't needed
+	repo := repository.NewUserRepository(db, nil)
+
+	user, err := repo.FindUserByEmail(*email)
+	if err != nil {
+		log.Fatalf("unable to find user: %s", err)
+	}
+
+	if *revoke {
+		err = repo.RemoveUserRole(user.ID, *role)
+	} else {
+		err = repo.AddUserRole(user.ID, *role)
+	}
+
+	if err != nil {
+		log.Fatalf("unable to update roles: %s", err)
+	}
+
+	if *revoke {
+		redis, err := cache.NewCache(&conf.Redis)
+		if err != nil {
+			log.Fatalf("redis setup error: %s", err)
+		}
+
+		auth := repository.NewAuthRepository(redis)
+		if err := auth.RevokeUserAccessTokens(user.ID, conf.Server.JWTMaxLifetime()); err != nil {
+			log.Fatalf("role is revoked, but access tokens of user are not: %s", err)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/usecase"
+	"github.com/Hickar/gin-rush/pkg/request"
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsers godoc
+// @Summary List users
+// @Description Search users by part of name or email. Requires "users:manage" permission.
+// @Produces json
+// @Param q query string false "Part of name or email"
+// @Param deleted query bool false "Include deleted users"
+// @Param page query int false "Page number, starting from 0, up to 10000"
+// @Param limit query int false "Page size, 20 by default"
+// @Success 200 {object} response.UserListResponse
+// @Failure 401
+// @Failure 403
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /admin/users [get]
+func (uc *UserController) ListUsers(c *gin.Context) {
+	var input request.ListUsersRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	users, err := uc.UserUseCase.ListUsers(input.Query, input.Deleted, input.Page, input.Limit)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUserRecord godoc
+// @Summary Get user record
+// @Description Get full record of user, including deleted one. Requires "users:manage" permission.
+// @Produces json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.AdminUserResponse
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /admin/users/{id} [get]
+func (uc *UserController) GetUserRecord(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := uc.UserUseCase.GetUserRecord(userID)
+	if err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ConfirmUserEmail godoc
+// @Summary Confirm user email
+// @Description Mark email of user as confirmed without confirmation code. Requires "users:manage" permission.
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/email/confirm [post]
+func (uc *UserController) ConfirmUserEmail(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := uc.UserUseCase.ConfirmUserEmail(userID); err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ForcePasswordReset godoc
+// @Summary Force password reset
+// @Description Invalidate password of user, log the user out everywhere and email password reset link. Requires "users:manage" permission.
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/password/reset [post]
+func (uc *UserController) ForcePasswordReset(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := uc.UserUseCase.ForcePasswordReset(userID); err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SuspendUser godoc
+// @Summary Suspend user
+// @Description Forbid user to log in until given time or indefinitely and terminate every session of the user, who is notified by email. Requires "users:manage" permission.
+// @Accept json
+// @Param id path int true "User ID"
+// @Param suspension body request.SuspendUserRequest true "JSON with reason and optional end of suspension"
+// @Success 204
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/suspension [post]
+func (uc *UserController) SuspendUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input request.SuspendUserRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	var until time.Time
+	if input.Until != nil {
+		until = *input.Until
+	}
+
+	if err := uc.UserUseCase.SuspendUser(userID, c.GetUint("user_id"), input.Reason, until); err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnsuspendUser godoc
+// @Summary Unsuspend user
+// @Description Allow suspended user to log in again. Requires "users:manage" permission.
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/suspension [delete]
+func (uc *UserController) UnsuspendUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := uc.UserUseCase.UnsuspendUser(userID); err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreUser godoc
+// @Summary Restore user
+// @Description Undo deletion of user account. Requires "users:manage" permission.
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/restore [post]
+func (uc *UserController) RestoreUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := uc.UserUseCase.RestoreUser(userID); err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// userIDParam parses user ID from path, responding with 422 if it's invalid
+func userIDParam(c *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return 0, false
+	}
+
+	return uint(userID), true
+}
+
+func adminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		c.Status(http.StatusNotFound)
+	case errors.Is(err, usecase.ErrUserNotDeleted):
+		c.Status(http.StatusConflict)
+	case errors.Is(err, usecase.ErrUnprocessableEntity):
+		c.Status(http.StatusUnprocessableEntity)
+	default:
+		c.Status(http.StatusInternalServerError)
+	}
+}
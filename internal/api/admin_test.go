@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestListUsers(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Query        string
+		DBMockSetup  func(mock sqlmock.Sqlmock)
+		ExpectedCode int
+	}{
+		{
+			"FirstPageShouldSucceed",
+			"?q=john",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users`").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectQuery("SELECT \\* FROM `users` WHERE (.+) ORDER BY id LIMIT 20").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "john", "john@example.com"))
+			},
+			http.StatusOK,
+		},
+		{"TooLargePageShouldFail", "?page=10001", func(mock sqlmock.Sqlmock) {}, http.StatusUnprocessableEntity},
+		{"TooLargeLimitShouldFail", "?limit=101", func(mock sqlmock.Sqlmock) {}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestController(t)
+			r := gin.New()
+			r.GET("/api/admin/users", tc.ListUsers)
+
+			tt.DBMockSetup(tc.db)
+
+			req, _ := http.NewRequest("GET", "/api/admin/users"+tt.Query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.ExpectedCode {
+				t.Fatalf("expected status %d, got %d instead", tt.ExpectedCode, w.Code)
+			}
+
+			if w.Code == http.StatusOK {
+				var resp response.UserListResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Total != 1 || len(resp.Users) != 1 {
+					t.Errorf("unexpected response body %s", w.Body.String())
+				}
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
+
+func TestGetUserRecord(t *testing.T) {
+	tests := []struct {
+		Name         string
+		ID           string
+		DBMockSetup  func(mock sqlmock.Sqlmock)
+		ExpectedCode int
+	}{
+		{
+			"DeletedUserShouldBeFound",
+			"1",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "deleted_at"}).AddRow(1, "user@example.com", time.Now()))
+				mock.ExpectQuery("SELECT `role` FROM `user_roles`").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"role"}))
+			},
+			http.StatusOK,
+		},
+		{
+			"MissingUserShouldNotBeFound",
+			"2",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(2).WillReturnError(gorm.ErrRecordNotFound)
+			},
+			http.StatusNotFound,
+		},
+		{"InvalidIDShouldFail", "abc", func(mock sqlmock.Sqlmock) {}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestController(t)
+			r := gin.New()
+			r.GET("/api/admin/users/:id", tc.GetUserRecord)
+
+			tt.DBMockSetup(tc.db)
+
+			req, _ := http.NewRequest("GET", "/api/admin/users/"+tt.ID, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.ExpectedCode {
+				t.Errorf("expected status %d, got %d instead", tt.ExpectedCode, w.Code)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	tests := []struct {
+		Name         string
+		DeletedAt    interface{}
+		ExpectedCode int
+	}{
+		{"DeletedUserShouldBeRestored", time.Now(), http.StatusNoContent},
+		{"ActiveUserShouldConflict", nil, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestController(t)
+			r := gin.New()
+			r.POST("/api/admin/users/:id/restore", tc.RestoreUser)
+
+			tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email", "deleted_at"}).AddRow(1, "user@example.com", tt.DeletedAt))
+			if tt.DeletedAt != nil {
+				tc.db.ExpectExec("UPDATE `users` SET `deleted_at`").WillReturnResult(sqlmock.NewResult(0, 1))
+				tc.cache.ExpectDel("users:1").SetVal(1)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/admin/users/1/restore", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.ExpectedCode {
+				t.Errorf("expected status %d, got %d instead", tt.ExpectedCode, w.Code)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
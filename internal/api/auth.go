@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Hickar/gin-rush/internal/usecase"
+	"github.com/Hickar/gin-rush/pkg/request"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshToken godoc
+// @Summary Refresh token pair
+// @Description Exchange refresh token for new JWT and refresh token. Each refresh token can be used only once, reusing it revokes all tokens issued after the same login.
+// @Accept json
+// @Produces json
+// @Param refresh_token body request.RefreshTokenRequest true "JSON with refresh token"
+// @Success 200 {object} response.AuthUserResponse{token=string,refresh_token=string}
+// @Failure 401
+// @Failure 403
+// @Failure 422
+// @Router /token/refresh [post]
+func (uc *UserController) RefreshToken(c *gin.Context) {
+	var input request.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	tokens, err := uc.UserUseCase.RefreshToken(input.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidRefreshToken), errors.Is(err, usecase.ErrRefreshTokenReused):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			c.Status(http.StatusForbidden)
+		case errors.Is(err, usecase.ErrUserSuspended):
+			c.JSON(http.StatusForbidden, suspensionResponse(err))
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke JWT used for request along with refresh tokens issued after the same login
+// @Produces json
+// @Success 204
+// @Failure 401
+// @Security ApiKeyAuth
+// @Router /logout [post]
+func (uc *UserController) Logout(c *gin.Context) {
+	claims, ok := c.MustGet("claims").(*security.Claims)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	if err := uc.UserUseCase.Logout(claims); err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutEverywhere godoc
+// @Summary Log out from all devices
+// @Description Revoke every JWT and refresh token issued to authenticated user
+// @Produces json
+// @Success 204
+// @Failure 401
+// @Security ApiKeyAuth
+// @Router /logout/all [post]
+func (uc *UserController) LogoutEverywhere(c *gin.Context) {
+	authUserID := c.GetUint("user_id")
+
+	if err := uc.UserUseCase.LogoutEverywhere(authUserID); err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying JWT issued by the API
+// @Produces json
+// @Success 200 {object} security.JWKS
+// @Router /.well-known/jwks.json [get]
+func (uc *UserController) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, uc.UserUseCase.JWKS())
+}
+
+// ResendConfirmation godoc
+// @Summary Resend confirmation email
+// @Description Send new email confirmation link if account isn't enabled yet, invalidating the previous one. Response is the same whether or not email is registered.
+// @Accept json
+// @Param email body request.ResendConfirmationRequest true "JSON with email"
+// @Success 202
+// @Failure 422
+// @Failure 429
+// @Router /authorize/email/resend [post]
+func (uc *UserController) ResendConfirmation(c *gin.Context) {
+	var input request.ResendConfirmationRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := uc.UserUseCase.ResendConfirmation(input.Email); err != nil {
+		setRetryAfter(c, err)
+
+		switch {
+		case errors.Is(err, usecase.ErrConfirmationThrottled):
+			c.Status(http.StatusTooManyRequests)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// RequestMagicLink godoc
+// @Summary Request magic link
+// @Description Send single-use sign-in link to email. Response is the same whether or not email is registered.
+// @Accept json
+// @Param email body request.MagicLinkRequest true "JSON with email"
+// @Success 202
+// @Failure 422
+// @Router /authorize/magic-link [post]
+func (uc *UserController) RequestMagicLink(c *gin.Context) {
+	var input request.MagicLinkRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	uc.UserUseCase.RequestMagicLink(input.Email)
+	c.Status(http.StatusAccepted)
+}
+
+// ConsumeMagicLink godoc
+// @Summary Sign in with magic link
+// @Description Exchange token from magic link for JWT, or for MFA token if two-factor authentication is enabled
+// @Accept json
+// @Produces json
+// @Param token body request.ConsumeMagicLinkRequest true "JSON with magic link token"
+// @Success 200 {object} response.AuthUserResponse{token=string,refresh_token=string}
+// @Failure 401
+// @Failure 403
+// @Failure 422
+// @Router /authorize/magic-link/consume [post]
+func (uc *UserController) ConsumeMagicLink(c *gin.Context) {
+	var input request.ConsumeMagicLinkRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	tokens, err := uc.UserUseCase.ConsumeMagicLink(input.Token, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidMagicLink):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			c.Status(http.StatusForbidden)
+		case errors.Is(err, usecase.ErrUserSuspended):
+			c.JSON(http.StatusForbidden, suspensionResponse(err))
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RequestPasswordReset godoc
+// @Summary Request password reset
+// @Description Send single-use password reset link to email. Response is the same whether or not email is registered.
+// @Accept json
+// @Param email body request.PasswordResetRequest true "JSON with email"
+// @Success 202
+// @Failure 422
+// @Router /authorize/password/reset [post]
+func (uc *UserController) RequestPasswordReset(c *gin.Context) {
+	var input request.PasswordResetRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	uc.UserUseCase.RequestPasswordReset(input.Email)
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary Complete password reset
+// @Description Set new password with token from password reset link. All sessions of user are terminated.
+// @Accept json
+// @Param reset body request.CompletePasswordResetRequest true "JSON with reset token and new password"
+// @Success 204
+// @Failure 401
+// @Failure 422 {object} response.ErrorResponse
+// @Failure 503 {object} response.ErrorResponse
+// @Router /authorize/password/reset/complete [post]
+func (uc *UserController) ResetPassword(c *gin.Context) {
+	var input request.CompletePasswordResetRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		uc.bindingError(c, err)
+		return
+	}
+
+	if err := uc.UserUseCase.ResetPassword(input.Token, input.Password); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidResetToken):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrPasswordBreached), errors.Is(err, usecase.ErrWeakPassword),
+			errors.Is(err, usecase.ErrPasswordReused):
+			c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, usecase.ErrPasswordHistory):
+			c.JSON(http.StatusServiceUnavailable, response.ErrorResponse{Error: err.Error()})
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Hickar/gin-rush/internal/usecase"
+	"github.com/Hickar/gin-rush/pkg/request"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ConfirmEmailPage godoc
+// @Summary Email confirmation page
+// @Description Page opened by link from verification message. It doesn't change anything by itself, so that prefetching the link by mail scanners doesn't confirm email, and only submits the code with POST request.
+// @Produces html
+// @Param code query string true "Confirmation code"
+// @Success 200
+// @Router /authorize/email/confirm [get]
+func (uc *UserController) ConfirmEmailPage(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.HTML(http.StatusOK, "confirm_email.html", gin.H{
+		"Action": c.Request.URL.Path,
+		"Code":   c.Query("code"),
+	})
+}
+
+// ConfirmEmail godoc
+// @Summary Confirm email
+// @Description Enable user with code from verification message. JSON request gets JWT in response, form submitted from confirmation page gets HTML page. Code can be used only once.
+// @Accept json,x-www-form-urlencoded
+// @Produces json,html
+// @Param code body request.ConfirmEmailRequest true "Confirmation code"
+// @Success 200 {object} response.AuthUserResponse{token=string,refresh_token=string}
+// @Failure 404
+// @Failure 410
+// @Failure 422
+// @Router /authorize/email/confirm [post]
+func (uc *UserController) ConfirmEmail(c *gin.Context) {
+	var input request.ConfirmEmailRequest
+
+	if c.ContentType() == binding.MIMEPOSTForm {
+		uc.confirmEmailForm(c)
+		return
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	tokens, err := uc.UserUseCase.EnableUser(input.Code, clientInfo(c))
+	if err != nil {
+		c.Status(confirmationErrorStatus(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// confirmEmailForm handles form submitted from confirmation page. User
+// isn't signed in, since browser has nowhere to keep tokens.
+func (uc *UserController) confirmEmailForm(c *gin.Context) {
+	var input request.ConfirmEmailRequest
+
+	c.Header("Cache-Control", "no-store")
+
+	if err := c.ShouldBindWith(&input, binding.FormPost); err != nil {
+		confirmationResult(c, usecase.ErrUnprocessableEntity)
+		return
+	}
+
+	_, err := uc.UserUseCase.ConfirmEmail(input.Code)
+	confirmationResult(c, err)
+}
+
+func confirmationResult(c *gin.Context, err error) {
+	if err == nil {
+		c.HTML(http.StatusOK, "confirm_email_result.html", gin.H{
+			"Title":   "Email confirmed",
+			"Message": "Your account is verified, you can close this page now.",
+		})
+		return
+	}
+
+	var message string
+	switch {
+	case errors.Is(err, usecase.ErrConfirmationExpired):
+		message = "Confirmation link has expired. Please request a new one."
+	case errors.Is(err, usecase.ErrUserNotFound), errors.Is(err, usecase.ErrUnprocessableEntity):
+		message = "Confirmation link is invalid. Please check that it was copied completely."
+	default:
+		message = "Something went wrong. Please try again later."
+	}
+
+	c.HTML(confirmationErrorStatus(err), "confirm_email_result.html", gin.H{
+		"Title":   "Email not confirmed",
+		"Message": message,
+	})
+}
+
+func confirmationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrConfirmationExpired):
+		return http.StatusGone
+	case errors.Is(err, usecase.ErrUnprocessableEntity):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, usecase.ErrUserSuspended):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/pkg/security"
+	"github.com/gin-gonic/gin"
+)
+
+func TestConfirmEmailExpired(t *testing.T) {
+	const code = "0123456789abcdefghijklmnopqrst"
+
+	tests := []struct {
+		Name        string
+		ContentType string
+		Body        string
+	}{
+		{"JSONShouldGetGone", "application/json", `{"code":"` + code + `"}`},
+		{"FormShouldGetGone", "application/x-www-form-urlencoded", "code=" + code},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestController(t)
+			r := gin.New()
+			r.SetHTMLTemplate(Templates())
+			r.POST("/api/authorize/email/confirm", tc.ConfirmEmail)
+
+			rows := sqlmock.NewRows([]string{"id", "email", "enabled", "confirmation_code", "confirmation_expires_at"}).
+				AddRow(1, "user@example.com", false, security.HashToken(code), time.Now().Add(-time.Minute))
+			tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(security.HashToken(code)).WillReturnRows(rows)
+
+			req, _ := http.NewRequest("POST", "/api/authorize/email/confirm", strings.NewReader(tt.Body))
+			req.Header.Set("Content-Type", tt.ContentType)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusGone {
+				t.Errorf("expected status %d, got %d instead", http.StatusGone, w.Code)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/broker"
+	"github.com/Hickar/gin-rush/internal/cache"
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/Hickar/gin-rush/internal/dbtest"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/internal/usecase"
+	"github.com/Hickar/gin-rush/pkg/logger"
+	"github.com/go-redis/redismock/v8"
+)
+
+type testController struct {
+	*UserController
+	db    sqlmock.Sqlmock
+	cache redismock.ClientMock
+}
+
+// newTestController creates controller with use case on top of mocked
+// database and Redis, and test configuration
+func newTestController(t *testing.T) *testController {
+	t.Helper()
+
+	conf := config.NewConfig("../../conf/config.test.json")
+	db, dbMock := dbtest.NewMockDB(t)
+	client, cacheMock := cache.NewCacheMock()
+	br, _ := broker.NewBrokerMock()
+	l, _ := logger.NewLoggerMock()
+
+	uc, err := usecase.NewUserUseCase(repository.NewUserRepository(db, client), repository.NewAuthRepository(client),
+		repository.NewSQLSessionRepository(db), conf, br, l)
+	if err != nil {
+		t.Fatalf("unable to create use case: %s", err)
+	}
+
+	return &testController{UserController: NewUserController(uc), db: dbMock, cache: cacheMock}
+}
+
+// expectationsMet fails test if some of database or Redis expectations
+// weren't met
+func (tc *testController) expectationsMet(t *testing.T) {
+	t.Helper()
+
+	if err := tc.db.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of DB expectations were not met: %s", err)
+	}
+
+	if err := tc.cache.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of Redis expectations were not met: %s", err)
+	}
+}
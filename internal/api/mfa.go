@@ -0,0 +1,302 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Hickar/gin-rush/internal/usecase"
+	"github.com/Hickar/gin-rush/pkg/request"
+	"github.com/Hickar/gin-rush/pkg/security"
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMFA godoc
+// @Summary Pass two-factor authentication
+// @Description Exchange MFA token returned by /authorize together with TOTP or recovery code for JWT. Wrong codes count as failed login attempts, and MFA token stops working after 5 of them.
+// @Accept json
+// @Produces json
+// @Param mfa body request.VerifyMFARequest true "JSON with MFA token and code"
+// @Success 200 {object} response.AuthUserResponse{token=string,refresh_token=string}
+// @Failure 401
+// @Failure 403
+// @Failure 422
+// @Failure 423
+// @Failure 429
+// @Router /authorize/mfa [post]
+func (uc *UserController) VerifyMFA(c *gin.Context) {
+	var input request.VerifyMFARequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	tokens, err := uc.UserUseCase.VerifyMFA(input.MFAToken, input.Code, input.RecoveryCode, clientInfo(c))
+	if err != nil {
+		setRetryAfter(c, err)
+
+		switch {
+		case errors.Is(err, usecase.ErrTooManyAttempts):
+			c.Status(http.StatusTooManyRequests)
+		case errors.Is(err, usecase.ErrAccountLocked):
+			c.Status(http.StatusLocked)
+		case errors.Is(err, usecase.ErrInvalidMFAToken), errors.Is(err, usecase.ErrInvalidMFACode):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrUserSuspended):
+			c.JSON(http.StatusForbidden, suspensionResponse(err))
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generate TOTP secret for authenticator app. Two-factor authentication is enabled only after confirmation with the first code.
+// @Produces json
+// @Success 200 {object} response.TOTPEnrollmentResponse{secret=string,uri=string}
+// @Failure 401
+// @Failure 404
+// @Failure 409
+// @Security ApiKeyAuth
+// @Router /user/mfa/totp [post]
+func (uc *UserController) EnrollTOTP(c *gin.Context) {
+	authUserID := c.GetUint("user_id")
+
+	enrollment, err := uc.UserUseCase.EnrollTOTP(authUserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, usecase.ErrMFAAlreadyEnabled):
+			c.Status(http.StatusConflict)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enable two-factor authentication with the first code from authenticator app. Response contains one-time recovery codes, which are shown only once.
+// @Accept json
+// @Produces json
+// @Param code body request.TOTPCodeRequest true "JSON with TOTP code"
+// @Success 200 {object} response.RecoveryCodesResponse{recovery_codes=[]string}
+// @Failure 401
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 423
+// @Failure 429
+// @Security ApiKeyAuth
+// @Router /user/mfa/totp/confirm [post]
+func (uc *UserController) ConfirmTOTP(c *gin.Context) {
+	var input request.TOTPCodeRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*security.Claims)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	codes, err := uc.UserUseCase.ConfirmTOTP(claims, input.Code, clientInfo(c))
+	if err != nil {
+		setRetryAfter(c, err)
+
+		switch {
+		case errors.Is(err, usecase.ErrTooManyAttempts):
+			c.Status(http.StatusTooManyRequests)
+		case errors.Is(err, usecase.ErrAccountLocked):
+			c.Status(http.StatusLocked)
+		case errors.Is(err, usecase.ErrUserNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, usecase.ErrMFAAlreadyEnabled), errors.Is(err, usecase.ErrMFANotEnabled):
+			c.Status(http.StatusConflict)
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			c.Status(http.StatusUnprocessableEntity)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Turn two-factor authentication off with current password and TOTP or recovery code
+// @Accept json
+// @Param code body request.DisableTOTPRequest true "JSON with current password and TOTP or recovery code"
+// @Success 204
+// @Failure 401
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 423
+// @Failure 429
+// @Security ApiKeyAuth
+// @Router /user/mfa/totp [delete]
+func (uc *UserController) DisableTOTP(c *gin.Context) {
+	var input request.DisableTOTPRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*security.Claims)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	err := uc.UserUseCase.DisableTOTP(claims, input.Password, input.Code, input.RecoveryCode, clientInfo(c))
+	if err != nil {
+		setRetryAfter(c, err)
+
+		switch {
+		case errors.Is(err, usecase.ErrTooManyAttempts):
+			c.Status(http.StatusTooManyRequests)
+		case errors.Is(err, usecase.ErrAccountLocked):
+			c.Status(http.StatusLocked)
+		case errors.Is(err, usecase.ErrUserNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, usecase.ErrMFANotEnabled):
+			c.Status(http.StatusConflict)
+		case errors.Is(err, usecase.ErrInvalidPassword):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			c.Status(http.StatusUnprocessableEntity)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmailMFA godoc
+// @Summary Pass two-factor authentication with email code
+// @Description Exchange MFA token returned by /authorize together with one-time code sent by email for JWT
+// @Accept json
+// @Produces json
+// @Param mfa body request.VerifyEmailMFARequest true "JSON with MFA token and email code"
+// @Success 200 {object} response.AuthUserResponse{token=string,refresh_token=string}
+// @Failure 401
+// @Failure 403
+// @Failure 422
+// @Failure 423
+// @Failure 429
+// @Router /authorize/mfa/email [post]
+func (uc *UserController) VerifyEmailMFA(c *gin.Context) {
+	var input request.VerifyEmailMFARequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	tokens, err := uc.UserUseCase.VerifyEmailMFA(input.MFAToken, input.Code, clientInfo(c))
+	if err != nil {
+		setRetryAfter(c, err)
+
+		switch {
+		case errors.Is(err, usecase.ErrTooManyAttempts):
+			c.Status(http.StatusTooManyRequests)
+		case errors.Is(err, usecase.ErrAccountLocked):
+			c.Status(http.StatusLocked)
+		case errors.Is(err, usecase.ErrInvalidMFAToken), errors.Is(err, usecase.ErrInvalidMFACode):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrUserSuspended):
+			c.JSON(http.StatusForbidden, suspensionResponse(err))
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// EnableEmailMFA godoc
+// @Summary Enable email two-factor authentication
+// @Description Require one-time code sent to confirmed email address after password check
+// @Success 204
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Security ApiKeyAuth
+// @Router /user/mfa/email [post]
+func (uc *UserController) EnableEmailMFA(c *gin.Context) {
+	authUserID := c.GetUint("user_id")
+
+	if err := uc.UserUseCase.EnableEmailMFA(authUserID); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, usecase.ErrMFAAlreadyEnabled):
+			c.Status(http.StatusConflict)
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			c.Status(http.StatusForbidden)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DisableEmailMFA godoc
+// @Summary Disable email two-factor authentication
+// @Description Turn email two-factor authentication off with current password
+// @Accept json
+// @Param password body request.PasswordConfirmationRequest true "JSON with current password"
+// @Success 204
+// @Failure 401
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /user/mfa/email [delete]
+func (uc *UserController) DisableEmailMFA(c *gin.Context) {
+	var input request.PasswordConfirmationRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	authUserID := c.GetUint("user_id")
+
+	if err := uc.UserUseCase.DisableEmailMFA(authUserID, input.Password); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, usecase.ErrMFANotEnabled):
+			c.Status(http.StatusConflict)
+		case errors.Is(err, usecase.ErrInvalidPassword):
+			c.Status(http.StatusUnauthorized)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
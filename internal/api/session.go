@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Hickar/gin-rush/internal/usecase"
+	"github.com/Hickar/gin-rush/pkg/security"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSessions godoc
+// @Summary List sessions
+// @Description Get devices authenticated user is logged in on, most recently used first
+// @Produces json
+// @Success 200 {array} response.SessionResponse
+// @Failure 401
+// @Security ApiKeyAuth
+// @Router /user/sessions [get]
+func (uc *UserController) GetSessions(c *gin.Context) {
+	claims, ok := c.MustGet("claims").(*security.Claims)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := uc.UserUseCase.GetSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Sign out session
+// @Description Log authenticated user out on device of the session. Its access and refresh tokens stop working immediately.
+// @Param id path string true "Session ID"
+// @Success 204
+// @Failure 401
+// @Failure 404
+// @Security ApiKeyAuth
+// @Router /user/sessions/{id} [delete]
+func (uc *UserController) RevokeSession(c *gin.Context) {
+	authUserID := c.GetUint("user_id")
+
+	if err := uc.UserUseCase.RevokeSession(authUserID, c.Param("id")); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrSessionNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// clientInfo describes device request came from for session tracking
+func clientInfo(c *gin.Context) usecase.ClientInfo {
+	return usecase.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
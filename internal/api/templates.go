@@ -0,0 +1,15 @@
+package api
+
+import (
+	"embed"
+	"html/template"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// Templates returns HTML templates of server-rendered pages, which should be
+// set on router with SetHTMLTemplate
+func Templates() *template.Template {
+	return template.Must(template.ParseFS(templateFiles, "templates/*.html"))
+}
@@ -2,13 +2,15 @@ package api
 
 import (
 	"errors"
-	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 
+	"github.com/Hickar/gin-rush/internal/models"
 	"github.com/Hickar/gin-rush/internal/usecase"
 	"github.com/Hickar/gin-rush/pkg/request"
 	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,49 +24,50 @@ func NewUserController(useCase *usecase.UserUseCase) *UserController {
 
 // CreateUser godoc
 // @Summary Create new user
-// @Description Create new user with credentials provided in request. Response contains user JWT.
+// @Description Create new user with credentials provided in request. Response contains user JWT, unless login is blocked until email is confirmed.
 // @Accept json
 // @Produces json
 // @Param new_user body request.CreateUserRequest true "JSON with user credentials"
-// @Success 201 {object} response.AuthUserResponse{token=string}
+// @Success 201 {object} response.AuthUserResponse{token=string,refresh_token=string}
 // @Failure 409
-// @Failure 422
+// @Failure 422 {object} response.ErrorResponse
 // @Router /user [post]
 func (uc *UserController) CreateUser(c *gin.Context) {
 	var input request.CreateUserRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		fmt.Printf("err: %s", err.Error())
-		c.Status(http.StatusUnprocessableEntity)
+		uc.bindingError(c, err)
 		return
 	}
 
-	token, err := uc.UserUseCase.CreateUser(input.Email, input.Name, input.Password)
+	tokens, err := uc.UserUseCase.CreateUser(input.Email, input.Name, input.Password, clientInfo(c))
 	if err != nil {
 		switch {
 		case errors.Is(err, usecase.ErrUserExists):
 			c.Status(http.StatusConflict)
+		case errors.Is(err, usecase.ErrPasswordBreached), errors.Is(err, usecase.ErrWeakPassword):
+			c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{Error: err.Error()})
 		default:
 			c.Status(http.StatusInternalServerError)
 		}
 		return
 	}
 
-	c.JSON(
-		http.StatusCreated,
-		response.AuthUserResponse{Token: token},
-	)
+	c.JSON(http.StatusCreated, tokens)
 }
 
 // AuthorizeUser godoc
 // @Summary Authorize user with username/password
-// @Description Method for authorizing user with credentials, returning signed jwt in response
+// @Description Method for authorizing user with credentials, returning signed jwt in response. If two-factor authentication is enabled, response contains MFA token to be passed to /authorize/mfa instead. Depending on email verification policy, users with unconfirmed email are either denied or get token marked with email_verified=false claim. Suspended users are rejected with 403 and description of suspension.
 // @Accept json
 // @Produces json
 // @Param login_user body request.AuthUserRequest true "JSON with credentials"
-// @Success 200 {object} response.AuthUserResponse{token=string}
+// @Success 200 {object} response.AuthUserResponse{token=string,refresh_token=string}
+// @Failure 403
 // @Failure 404
 // @Failure 422
+// @Failure 423
+// @Failure 429
 // @Router /authorize [post]
 func (uc *UserController) AuthorizeUser(c *gin.Context) {
 	var input request.AuthUserRequest
@@ -74,23 +77,60 @@ func (uc *UserController) AuthorizeUser(c *gin.Context) {
 		return
 	}
 
-	token, err := uc.UserUseCase.AuthorizeUser(input.Email, input.Password)
+	tokens, err := uc.UserUseCase.AuthorizeUser(input.Email, input.Password, clientInfo(c))
 	if err != nil {
+		setRetryAfter(c, err)
+
 		switch {
+		case errors.Is(err, usecase.ErrTooManyAttempts):
+			c.Status(http.StatusTooManyRequests)
+		case errors.Is(err, usecase.ErrAccountLocked):
+			c.Status(http.StatusLocked)
 		case errors.Is(err, usecase.ErrUserNotFound):
 			c.Status(http.StatusNotFound)
 		case errors.Is(err, usecase.ErrInvalidPassword):
 			c.Status(http.StatusConflict)
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			c.Status(http.StatusForbidden)
+		case errors.Is(err, usecase.ErrUserSuspended):
+			c.JSON(http.StatusForbidden, suspensionResponse(err))
 		default:
 			c.Status(http.StatusInternalServerError)
 		}
 		return
 	}
 
-	c.JSON(
-		http.StatusOK,
-		response.AuthUserResponse{Token: token},
-	)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// setRetryAfter sets Retry-After header if request was rejected by throttling
+func setRetryAfter(c *gin.Context, err error) {
+	var retryErr *usecase.RetryAfterError
+	if errors.As(err, &retryErr) {
+		seconds := int64(math.Ceil(retryErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+}
+
+// suspensionResponse describes suspension which caused login rejection
+func suspensionResponse(err error) *response.SuspensionResponse {
+	var suspended *usecase.SuspendedError
+	if errors.As(err, &suspended) {
+		return suspended.Response()
+	}
+
+	return &response.SuspensionResponse{Error: err.Error()}
+}
+
+// canAccessUser reports whether request is made by the user itself or by
+// someone granted permission to act on other users
+func canAccessUser(c *gin.Context, userID uint, permission string) bool {
+	if userID == c.GetUint("user_id") {
+		return true
+	}
+
+	claims, ok := c.Get("claims")
+	return ok && claims.(*security.Claims).HasPermission(permission)
 }
 
 // UpdateUser godoc
@@ -132,9 +172,51 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// ChangePassword godoc
+// @Summary Change password
+// @Description Replace password of authenticated user. Current password is required, notice is sent to user email.
+// @Accept json
+// @Param password body request.ChangePasswordRequest true "JSON with current and new password"
+// @Success 204
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 422 {object} response.ErrorResponse
+// @Failure 503 {object} response.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /user/password [put]
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	var input request.ChangePasswordRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		uc.bindingError(c, err)
+		return
+	}
+
+	authUserID := c.GetUint("user_id")
+	if err := uc.UserUseCase.ChangePassword(authUserID, input.CurrentPassword, input.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, usecase.ErrInvalidPassword):
+			c.Status(http.StatusForbidden)
+		case errors.Is(err, usecase.ErrPasswordBreached), errors.Is(err, usecase.ErrWeakPassword),
+			errors.Is(err, usecase.ErrPasswordReused):
+			c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, usecase.ErrPasswordHistory):
+			c.JSON(http.StatusServiceUnavailable, response.ErrorResponse{Error: err.Error()})
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // GetUser godoc
 // @Summary Get user
-// @Description Get user by id
+// @Description Get user by id. Users without "users:read" permission can only get themselves.
 // @Accept json
 // @Produces json
 // @Param user_id path int true "User ID"
@@ -152,13 +234,12 @@ func (uc *UserController) GetUser(c *gin.Context) {
 		return
 	}
 
-	authUserID := c.GetUint("user_id")
-	if uint(userID) != authUserID {
+	if !canAccessUser(c, uint(userID), models.PermissionReadUsers) {
 		c.Status(http.StatusForbidden)
 		return
 	}
 
-	userResp, err := uc.UserUseCase.GetUser(authUserID)
+	userResp, err := uc.UserUseCase.GetUser(uint(userID))
 	if err != nil {
 		switch {
 		case errors.Is(err, usecase.ErrUserNotFound):
@@ -174,7 +255,7 @@ func (uc *UserController) GetUser(c *gin.Context) {
 
 // DeleteUser godoc
 // @Summary Delete user
-// @Description Delete user by id
+// @Description Delete user by id. Users without "users:delete" permission can only delete themselves.
 // @Accept json
 // @Produces json
 // @Param user_id path int true "User ID"
@@ -192,13 +273,12 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	authUserID := c.GetUint("user_id")
-	if uint(userID) != authUserID {
+	if !canAccessUser(c, uint(userID), models.PermissionDeleteUsers) {
 		c.Status(http.StatusForbidden)
 		return
 	}
 
-	if err := uc.UserUseCase.DeleteUser(authUserID); err != nil {
+	if err := uc.UserUseCase.DeleteUser(uint(userID)); err != nil {
 		switch {
 		case errors.Is(err, usecase.ErrUserNotFound):
 			c.Status(http.StatusNotFound)
@@ -213,29 +293,95 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 
 // EnableUser godoc
 // @Summary Enable user
-// @Description Method for enabling user via verification message sent by email
+// @Description Method for enabling user via verification message sent by email. Deprecated in favor of POST /authorize/email/confirm, available only if legacy_email_challenge is set in config.
 // @Produces json
 // @Param confirmation_code path string true "Confirmation code"
-// @Success 200 {object} response.AuthUserResponse{token=string}
+// @Success 200 {object} response.AuthUserResponse{token=string,refresh_token=string}
 // @Failure 404
+// @Failure 410
 // @Failure 422
+// @Deprecated
 // @Router /authorize/email/challenge/{code} [get]
 func (uc *UserController) EnableUser(c *gin.Context) {
 	code := c.Param("code")
 
-	token, err := uc.UserUseCase.EnableUser(code)
+	tokens, err := uc.UserUseCase.EnableUser(code, clientInfo(c))
 	if err != nil {
+		c.Status(confirmationErrorStatus(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// ChangeEmail godoc
+// @Summary Change email
+// @Description Request email change for authenticated user. Email is replaced only after the new address is confirmed with link sent to it.
+// @Accept json
+// @Param email body request.ChangeEmailRequest true "JSON with new email and current password"
+// @Success 202
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Security ApiKeyAuth
+// @Router /user/email [put]
+func (uc *UserController) ChangeEmail(c *gin.Context) {
+	var input request.ChangeEmailRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	authUserID := c.GetUint("user_id")
+	if err := uc.UserUseCase.RequestEmailChange(authUserID, input.Email, input.Password); err != nil {
 		switch {
 		case errors.Is(err, usecase.ErrUserNotFound):
 			c.Status(http.StatusNotFound)
-		case errors.Is(err, usecase.ErrUnprocessableEntity):
-			c.Status(http.StatusUnprocessableEntity)
+		case errors.Is(err, usecase.ErrInvalidPassword):
+			c.Status(http.StatusForbidden)
+		case errors.Is(err, usecase.ErrUserExists):
+			c.Status(http.StatusConflict)
+		default:
+			c.Status(http.StatusInternalServerError)
 		}
 		return
 	}
 
-	c.JSON(
-		http.StatusOK,
-		response.AuthUserResponse{Token: token},
-	)
-}
\ No newline at end of file
+	c.Status(http.StatusAccepted)
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm email change
+// @Description Replace user email with the new address using token from confirmation link
+// @Accept json
+// @Param token body request.ConfirmEmailChangeRequest true "JSON with email change token"
+// @Success 204
+// @Failure 401
+// @Failure 409
+// @Failure 422
+// @Router /authorize/email/change [post]
+func (uc *UserController) ConfirmEmailChange(c *gin.Context) {
+	var input request.ConfirmEmailChangeRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := uc.UserUseCase.ConfirmEmailChange(input.Token); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidEmailToken):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrUserExists):
+			c.Status(http.StatusConflict)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// bindingError responds with 422 to request which body failed validation.
+// Password policy violations are described in response body, since client
+// can't tell which requirement wasn't met from status alone.
+func (uc *UserController) bindingError(c *gin.Context, err error) {
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		for _, fieldErr := range fieldErrs {
+			if fieldErr.Tag() != "validpassword" {
+				continue
+			}
+
+			password, _ := fieldErr.Value().(string)
+			violations := uc.UserUseCase.PasswordPolicy().Validate(password)
+			c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{Error: "password " + strings.Join(violations, ", ")})
+			return
+		}
+	}
+
+	c.Status(http.StatusUnprocessableEntity)
+}
@@ -5,28 +5,155 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"time"
 )
 
 var _config *Config
 
+// ReleaseMode is server mode of production deployments, same as gin.ReleaseMode
+const ReleaseMode = "release"
+
+// Email verification policies. Block denies login until email is confirmed,
+// restrict issues tokens which are rejected by routes requiring verified
+// email, allow doesn't treat unverified accounts differently.
+const (
+	EmailVerificationBlock    = "block"
+	EmailVerificationRestrict = "restrict"
+	EmailVerificationAllow    = "allow"
+)
+
+// Kinds of email confirmation tokens. Code is random value stored hashed
+// with the user, signed is stateless token verified with TokenSigningKey.
+const (
+	ConfirmationTokenCode   = "code"
+	ConfirmationTokenSigned = "signed"
+)
+
+// Session storage backends
+const (
+	SessionStoreRedis = "redis"
+	SessionStoreSQL   = "sql"
+)
+
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Rollbar  RollbarConfig  `json:"rollbar"`
-	Redis    RedisConfig    `json:"redis"`
-	RabbitMQ RabbitMQConfig `json:"rabbitmq"`
-	Gmail    GmailConfig    `json:"gmail"`
+	Server    ServerConfig    `json:"server"`
+	Database  DatabaseConfig  `json:"database"`
+	Rollbar   RollbarConfig   `json:"rollbar"`
+	Redis     RedisConfig     `json:"redis"`
+	RabbitMQ  RabbitMQConfig  `json:"rabbitmq"`
+	Gmail     GmailConfig     `json:"gmail"`
+	RateLimit RateLimitConfig `json:"rate_limit"`
+	Password  PasswordConfig  `json:"password"`
+	// Roles maps role names to permissions they grant, overriding built-in
+	// roles if set
+	Roles map[string][]string `json:"roles,omitempty"`
 }
 
+// ServerConfig holds HTTP server and authentication settings.
+// LegacyEmailChallenge enables email confirmation by GET request, which is
+// triggered by mail scanners prefetching links, for messages sent before
+// confirmation page was introduced. ConfirmationTokens is either "code" or
+// "signed", the latter requires base64-encoded TokenSigningKey of at least
+// 32 bytes. SessionStore is either "redis" or "sql".
 type ServerConfig struct {
-	Mode            string `json:"mode"`
-	Port            int    `json:"port"`
-	Debug           bool   `json:"debug,omitempty"`
-	HostUrl         string `json:"host_url"`
-	ApiUrl          string `json:"api_url"`
-	JWTSecret       string `json:"jwt_secret"`
-	JWTHeader       string `json:"jwt_header"`
-	JWTBearerPrefix string `json:"jwt_bearer_prefix"`
+	Mode                 string         `json:"mode"`
+	Port                 int            `json:"port"`
+	Debug                bool           `json:"debug,omitempty"`
+	HostUrl              string         `json:"host_url"`
+	ApiUrl               string         `json:"api_url"`
+	JWTSecret            string         `json:"jwt_secret,omitempty"`
+	JWTKeys              []JWTKeyConfig `json:"jwt_keys,omitempty"`
+	JWTTTL               int            `json:"jwt_ttl,omitempty"`
+	JWTIssuer            string         `json:"jwt_issuer,omitempty"`
+	JWTAudience          []string       `json:"jwt_audience,omitempty"`
+	JWTLeeway            int            `json:"jwt_leeway,omitempty"`
+	JWTHeader            string         `json:"jwt_header"`
+	JWTBearerPrefix      string         `json:"jwt_bearer_prefix"`
+	RefreshTokenTTL      int            `json:"refresh_token_ttl,omitempty"`
+	MFAIssuer            string         `json:"mfa_issuer,omitempty"`
+	MFAEncryptKey        string         `json:"mfa_encrypt_key"`
+	EmailVerification    string         `json:"email_verification,omitempty"`
+	LegacyEmailChallenge bool           `json:"legacy_email_challenge,omitempty"`
+	ConfirmationTokens   string         `json:"confirmation_tokens,omitempty"`
+	TokenSigningKey      string         `json:"token_signing_key,omitempty"`
+	SessionStore         string         `json:"session_store,omitempty"`
+}
+
+// JWTMaxLifetime is the longest period access token may be accepted for,
+// including clock skew leeway
+func (c *ServerConfig) JWTMaxLifetime() time.Duration {
+	return time.Duration(c.JWTTTL+c.JWTLeeway) * time.Second
+}
+
+// JWTKeyConfig describes asymmetric JWT key stored in PEM files.
+// Algorithm is one of "RS256", "ES256" or "EdDSA". ActiveFrom and RetireAt
+// are RFC 3339 timestamps bounding the period key is used for signing and
+// verification respectively.
+type JWTKeyConfig struct {
+	ID             string `json:"id"`
+	Algorithm      string `json:"algorithm"`
+	PrivateKeyPath string `json:"private_key_path,omitempty"`
+	PublicKeyPath  string `json:"public_key_path,omitempty"`
+	ActiveFrom     string `json:"active_from,omitempty"`
+	RetireAt       string `json:"retire_at,omitempty"`
+}
+
+// RateLimitConfig holds request limits for route groups, such as "public"
+// and "user". Requests are counted by client IP, authenticated user ID or
+// API key passed in APIKeyHeader.
+type RateLimitConfig struct {
+	APIKeyHeader string                   `json:"api_key_header,omitempty"`
+	Groups       map[string]RateLimitRule `json:"groups,omitempty"`
+}
+
+// RateLimitRule allows Requests per Window seconds. KeyBy is one of "ip",
+// "user" or "api_key".
+type RateLimitRule struct {
+	Requests int    `json:"requests"`
+	Window   int    `json:"window"`
+	KeyBy    string `json:"key_by"`
+}
+
+// PasswordConfig holds argon2id cost parameters and peppers. Memory is set
+// in KiB. Changing parameters or current pepper makes existing hashes
+// rehashed on the next successful login, so previous pepper should be kept
+// until most of users signed in. Pepper key is base64-encoded random key of
+// at least 32 bytes, the one in config.prod.example.json is a placeholder
+// which must be replaced with key generated by e.g. "openssl rand -base64 32".
+//
+// New passwords are checked against breached passwords corpus if its path
+// is set. Format is either "range_dir" for directory of HIBP range files or
+// "bloom" for filter built from them with cmd/breach-bloom.
+//
+// HistorySize is number of last passwords, including the current one, which
+// can't be reused on change or reset. Zero disables password history.
+type PasswordConfig struct {
+	Argon2Memory      uint32               `json:"argon2_memory,omitempty"`
+	Argon2Iterations  uint32               `json:"argon2_iterations,omitempty"`
+	Argon2Parallelism uint8                `json:"argon2_parallelism,omitempty"`
+	Peppers           []PepperConfig       `json:"peppers,omitempty"`
+	CurrentPepper     string               `json:"current_pepper,omitempty"`
+	BreachedFormat    string               `json:"breached_format,omitempty"`
+	BreachedPath      string               `json:"breached_path,omitempty"`
+	HistorySize       int                  `json:"history_size,omitempty"`
+	Policy            PasswordPolicyConfig `json:"policy"`
+}
+
+// PasswordPolicyConfig describes requirements for new passwords. Required
+// classes are any of "upper", "lower", "digit" and "symbol", all of them are
+// required if list is omitted. MinEntropy is strength score in bits.
+type PasswordPolicyConfig struct {
+	MinLength       int      `json:"min_length,omitempty"`
+	MaxLength       int      `json:"max_length,omitempty"`
+	RequiredClasses []string `json:"required_classes"`
+	MinEntropy      float64  `json:"min_entropy,omitempty"`
+	BannedWords     []string `json:"banned_words,omitempty"`
+}
+
+// PepperConfig is a base64-encoded secret key with ID recorded in hashes
+type PepperConfig struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
 }
 
 type RollbarConfig struct {
@@ -88,10 +215,66 @@ func NewConfig(filePath string) *Config {
 		log.Fatalf("config file unmarshalling error: %s\ncurrent wd: %s\nconfiguration path:%s", err, currentPath, filePath)
 	}
 
+	config.setDefaults()
+
 	_config = &config
 	return &config
 }
 
+// setDefaults fills optional settings which were omitted in configuration file
+func (c *Config) setDefaults() {
+	if c.Server.JWTTTL == 0 {
+		c.Server.JWTTTL = 60 * 30
+	}
+
+	if c.Server.MFAIssuer == "" {
+		c.Server.MFAIssuer = "Gin-Rush"
+	}
+
+	if c.Server.RefreshTokenTTL == 0 {
+		c.Server.RefreshTokenTTL = 60 * 60 * 24 * 30
+	}
+
+	if c.Server.EmailVerification == "" {
+		c.Server.EmailVerification = EmailVerificationAllow
+	}
+
+	if c.Server.ConfirmationTokens == "" {
+		c.Server.ConfirmationTokens = ConfirmationTokenCode
+	}
+
+	if c.Server.SessionStore == "" {
+		c.Server.SessionStore = SessionStoreRedis
+	}
+
+	if c.Password.Argon2Memory == 0 {
+		c.Password.Argon2Memory = 64 * 1024
+	}
+
+	if c.Password.Argon2Iterations == 0 {
+		c.Password.Argon2Iterations = 3
+	}
+
+	if c.Password.Argon2Parallelism == 0 {
+		c.Password.Argon2Parallelism = 2
+	}
+
+	if c.Password.CurrentPepper == "" && len(c.Password.Peppers) > 0 {
+		c.Password.CurrentPepper = c.Password.Peppers[len(c.Password.Peppers)-1].ID
+	}
+
+	if c.RateLimit.APIKeyHeader == "" {
+		c.RateLimit.APIKeyHeader = "X-API-Key"
+	}
+
+	if c.RateLimit.Groups == nil {
+		c.RateLimit.Groups = map[string]RateLimitRule{
+			"public": {Requests: 30, Window: 60, KeyBy: "ip"},
+			"user":   {Requests: 120, Window: 60, KeyBy: "user"},
+		}
+	}
+}
+
 func GetConfig() *Config {
 	return _config
 }
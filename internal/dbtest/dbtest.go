@@ -0,0 +1,29 @@
+// Package dbtest provides sqlmock-backed database for tests
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/pkg/database"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// NewMockDB creates database backed by sqlmock. Writes aren't wrapped in
+// transactions, so only the statements themselves need to be expected.
+func NewMockDB(t testing.TB) (*database.Database, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock setup error: %s", err)
+	}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("mock db setup error: %s", err)
+	}
+
+	return &database.Database{DB: db}, mock
+}
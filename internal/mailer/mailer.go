@@ -5,6 +5,9 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"html"
+	"net/url"
+	"time"
 
 	"github.com/Hickar/gin-rush/internal/config"
 	"golang.org/x/oauth2"
@@ -12,10 +15,79 @@ import (
 	"google.golang.org/api/option"
 )
 
+// Exchange and routing keys of messages consumed by mailer worker.
+// Worker binds to KeysPattern, so new message types only need a new key.
+const (
+	Exchange            = "mailer_ex"
+	KeysPattern         = "mailer.#"
+	ConfirmationKey     = "mailer"
+	OneTimeCodeKey      = "mailer.otp"
+	MagicLinkKey        = "mailer.magic_link"
+	PasswordResetKey    = "mailer.password_reset"
+	PasswordChangedKey  = "mailer.password_changed"
+	EmailChangeKey      = "mailer.email_change"
+	EmailChangedKey     = "mailer.email_changed"
+	AccountLockedKey    = "mailer.account_locked"
+	AccountSuspendedKey = "mailer.account_suspended"
+)
+
+// ConfirmationMessage carries email confirmation code, which expires in
+// given number of hours
 type ConfirmationMessage struct {
 	Username string
 	Email    string
 	Code     string
+	Hours    int
+}
+
+type OneTimeCodeMessage struct {
+	Username string
+	Email    string
+	Code     string
+}
+
+type MagicLinkMessage struct {
+	Username string
+	Email    string
+	Token    string
+}
+
+type PasswordResetMessage struct {
+	Username string
+	Email    string
+	Token    string
+}
+
+type PasswordChangedMessage struct {
+	Username string
+	Email    string
+}
+
+type EmailChangeMessage struct {
+	Username string
+	Email    string
+	Token    string
+}
+
+type EmailChangedMessage struct {
+	Username string
+	Email    string
+	NewEmail string
+}
+
+type AccountLockedMessage struct {
+	Username string
+	Email    string
+	Minutes  int
+}
+
+// AccountSuspendedMessage notifies about suspension, which is indefinite if
+// Until is zero
+type AccountSuspendedMessage struct {
+	Username string
+	Email    string
+	Reason   string
+	Until    time.Time
 }
 
 type Credentials struct {
@@ -67,13 +139,75 @@ func NewMailer(conf *config.GmailConfig) (*Mailer, error) {
 	return &Mailer{GmailService: srv}, nil
 }
 
-func (m *Mailer) SendConfirmationCode(username, email, code string) error {
-	challengeLink := config.GetConfig().Server.HostUrl + "/authorize/email/challenge/" + code
+func (m *Mailer) SendConfirmationCode(username, email, code string, hours int) error {
+	conf := config.GetConfig().Server
+	challengeLink := conf.HostUrl + conf.ApiUrl + "/authorize/email/confirm?code=" + url.QueryEscape(code)
 	body := fmt.Sprintf("Hello <b>%s</b>!<br/>In order to verify your account, please proceed to following link: <a href=\"%s\">%s</a>", username, challengeLink, challengeLink)
 
+	// Messages queued before expiry was passed don't have it
+	if hours > 0 {
+		body += fmt.Sprintf("<br/>The link expires in %d hours.", hours)
+	}
+
 	return m.SendMail(email, "Account verification", body)
 }
 
+func (m *Mailer) SendOneTimeCode(username, email, code string) error {
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>Your sign-in code is <b>%s</b>. It expires in 10 minutes.<br/>If you didn't try to sign in, please change your password.", username, code)
+
+	return m.SendMail(email, "Sign-in code", body)
+}
+
+func (m *Mailer) SendMagicLink(username, email, token string) error {
+	link := config.GetConfig().Server.HostUrl + "/authorize/magic-link?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>To sign in, please proceed to following link: <a href=\"%s\">%s</a><br/>The link expires in 15 minutes and can be used only once. If you didn't request it, just ignore this email.", username, link, link)
+
+	return m.SendMail(email, "Sign-in link", body)
+}
+
+func (m *Mailer) SendPasswordResetLink(username, email, token string) error {
+	link := config.GetConfig().Server.HostUrl + "/authorize/password/reset?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>To set new password, please proceed to following link: <a href=\"%s\">%s</a><br/>The link expires in 1 hour and can be used only once. If you didn't request password reset, just ignore this email.", username, link, link)
+
+	return m.SendMail(email, "Password reset", body)
+}
+
+func (m *Mailer) SendPasswordChangedNotice(username, email string) error {
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>Password of your account was just changed.<br/>If it wasn't you, please reset your password immediately.", username)
+
+	return m.SendMail(email, "Your password was changed", body)
+}
+
+func (m *Mailer) SendEmailChangeLink(username, email, token string) error {
+	link := config.GetConfig().Server.HostUrl + "/authorize/email/change?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>To confirm this email address for your account, please proceed to following link: <a href=\"%s\">%s</a><br/>The link expires in 24 hours.", username, link, link)
+
+	return m.SendMail(email, "Email address confirmation", body)
+}
+
+func (m *Mailer) SendEmailChangedNotice(username, email, newEmail string) error {
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>There was a request to change email address of your account to <b>%s</b>. The change takes effect once the new address is confirmed.<br/>If it wasn't you, please reset your password immediately.", username, newEmail)
+
+	return m.SendMail(email, "Email address change", body)
+}
+
+func (m *Mailer) SendAccountLockedNotice(username, email string, minutes int) error {
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>Due to many failed sign-in attempts your account is locked for %d minutes.<br/>If it wasn't you, consider changing your password and enabling two-factor authentication.", username, minutes)
+
+	return m.SendMail(email, "Account temporarily locked", body)
+}
+
+func (m *Mailer) SendAccountSuspendedNotice(username, email, reason string, until time.Time) error {
+	period := "until further notice"
+	if !until.IsZero() {
+		period = "until " + until.UTC().Format("January 2, 2006 15:04 MST")
+	}
+
+	body := fmt.Sprintf("Hello <b>%s</b>!<br/>Your account is suspended %s.<br/>Reason: %s", username, period, html.EscapeString(reason))
+
+	return m.SendMail(email, "Account suspended", body)
+}
+
 func (m *Mailer) SendMail(to, subject, body string) error {
 	var message gmail.Message
 
@@ -1,15 +1,31 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/Hickar/gin-rush/internal/config"
-	"github.com/Hickar/gin-rush/internal/security"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
 	"github.com/gin-gonic/gin"
 )
 
-func JWT() gin.HandlerFunc {
+// Authenticator validates raw access token, returning its claims
+type Authenticator interface {
+	Authenticate(token string) (*security.Claims, error)
+}
+
+// Suspension is implemented by error Authenticator returns for token of
+// suspended user, so that the user is told about suspension
+type Suspension interface {
+	error
+	Response() *response.SuspensionResponse
+}
+
+// JWT authenticates request by access token. Token of suspended user is
+// rejected with 403 and description of suspension.
+func JWT(auth Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var claims *security.Claims
 		var err error
@@ -21,7 +37,13 @@ func JWT() gin.HandlerFunc {
 		if token == "" {
 			code = http.StatusUnauthorized
 		} else {
-			claims, err = security.ParseJWT(token, conf.JWTSecret)
+			claims, err = auth.Authenticate(token)
+
+			var suspended Suspension
+			if errors.As(err, &suspended) {
+				c.AbortWithStatusJSON(http.StatusForbidden, suspended.Response())
+				return
+			}
 
 			if err != nil {
 				code = http.StatusUnauthorized
@@ -34,6 +56,54 @@ func JWT() gin.HandlerFunc {
 		}
 
 		c.Set("user_id", claims.UserID)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail rejects requests authenticated with token issued to
+// user with unconfirmed email. It must follow JWT middleware. With policy
+// other than "restrict" every request passes, since such tokens are either
+// never issued or allowed everywhere.
+func RequireVerifiedEmail(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy != config.EmailVerificationRestrict {
+			c.Next()
+			return
+		}
+
+		claims, ok := c.Get("claims")
+		if !ok {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		if !claims.(*security.Claims).EmailVerified {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission rejects requests authenticated with token which lacks
+// any of the permissions. It must follow JWT middleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get("claims")
+		if !ok {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		for _, permission := range permissions {
+			if !claims.(*security.Claims).HasPermission(permission) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		}
+
 		c.Next()
 	}
 }
@@ -1,21 +1,71 @@
 package middleware
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Hickar/gin-rush/internal/config"
-	"github.com/Hickar/gin-rush/internal/security"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
 	"github.com/gin-gonic/gin"
 )
 
-func TestJWT(t *testing.T) {
+// suspensionStub is error reported for token of suspended user
+type suspensionStub struct {
+	until time.Time
+}
+
+func (s *suspensionStub) Error() string {
+	return "user account is suspended"
+}
+
+func (s *suspensionStub) Response() *response.SuspensionResponse {
+	return &response.SuspensionResponse{Error: s.Error(), Until: &s.until}
+}
+
+type authenticatorStub struct {
+	jwt       *security.JWTManager
+	revoked   map[string]bool
+	suspended map[uint]bool
+}
+
+func (a *authenticatorStub) Authenticate(token string) (*security.Claims, error) {
+	claims, err := a.jwt.ParseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.suspended[claims.UserID] {
+		return nil, &suspensionStub{until: time.Now().Add(time.Hour)}
+	}
+
+	if a.revoked[claims.Id] {
+		return nil, errors.New("token revoked")
+	}
+
+	return claims, nil
+}
+
+// newAuthenticatorStub creates authenticator of tokens signed with key from
+// test configuration, none of which are revoked
+func newAuthenticatorStub() (*authenticatorStub, *config.Config) {
 	gin.SetMode(gin.TestMode)
 	conf := config.NewConfig("../../conf/config.test.json")
+	keys, _ := security.NewKeyRing(conf.Server.JWTSecret, nil)
+	manager := security.NewJWTManager(keys, &conf.Server)
+
+	return &authenticatorStub{jwt: manager, revoked: map[string]bool{}, suspended: map[uint]bool{}}, conf
+}
+
+func TestJWT(t *testing.T) {
+	auth, conf := newAuthenticatorStub()
 	r := gin.New()
-	r.Use(JWT())
+	r.Use(JWT(auth))
 	r.GET("/endpoint", func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
@@ -36,7 +86,7 @@ func TestJWT(t *testing.T) {
 
 	t.Run("ValidToken", func(t *testing.T) {
 		expectedCode := http.StatusOK
-		token, _ := security.GenerateJWT(uint(0), conf.Server.JWTSecret)
+		token, _ := auth.jwt.GenerateJWT(&security.Claims{UserID: 0})
 
 		req, _ := http.NewRequest("GET", "/endpoint", nil)
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
@@ -48,4 +98,115 @@ func TestJWT(t *testing.T) {
 			t.Errorf("expected code %d, got %d instead", expectedCode, w.Code)
 		}
 	})
-}
\ No newline at end of file
+
+	t.Run("RevokedToken", func(t *testing.T) {
+		expectedCode := http.StatusUnauthorized
+		token, _ := auth.jwt.GenerateJWT(&security.Claims{UserID: 0})
+		claims, _ := auth.jwt.ParseJWT(token)
+		auth.revoked[claims.Id] = true
+
+		req, _ := http.NewRequest("GET", "/endpoint", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != expectedCode {
+			t.Errorf("expected code %d, got %d instead", expectedCode, w.Code)
+		}
+	})
+
+	t.Run("SuspendedUser", func(t *testing.T) {
+		expectedCode := http.StatusForbidden
+		token, _ := auth.jwt.GenerateJWT(&security.Claims{UserID: 7})
+		auth.suspended[7] = true
+
+		req, _ := http.NewRequest("GET", "/endpoint", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != expectedCode {
+			t.Errorf("expected code %d, got %d instead", expectedCode, w.Code)
+		}
+
+		var resp response.SuspensionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Until == nil {
+			t.Errorf("expected suspension description, got %q instead", w.Body.String())
+		}
+	})
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	auth, _ := newAuthenticatorStub()
+
+	tests := []struct {
+		Name          string
+		Policy        string
+		EmailVerified bool
+		ExpectedCode  int
+	}{
+		{"RestrictVerified", config.EmailVerificationRestrict, true, http.StatusOK},
+		{"RestrictUnverified", config.EmailVerificationRestrict, false, http.StatusForbidden},
+		{"AllowUnverified", config.EmailVerificationAllow, false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(JWT(auth), RequireVerifiedEmail(tt.Policy))
+			r.GET("/endpoint", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			token, _ := auth.jwt.GenerateJWT(&security.Claims{UserID: 1, EmailVerified: tt.EmailVerified})
+
+			req, _ := http.NewRequest("GET", "/endpoint", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.ExpectedCode {
+				t.Errorf("expected code %d, got %d instead", tt.ExpectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	auth, _ := newAuthenticatorStub()
+
+	r := gin.New()
+	r.Use(JWT(auth), RequirePermission("users:read", "users:delete"))
+	r.GET("/endpoint", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		Name         string
+		Permissions  []string
+		ExpectedCode int
+	}{
+		{"AllPermissions", []string{"users:delete", "users:read"}, http.StatusOK},
+		{"MissingPermission", []string{"users:read"}, http.StatusForbidden},
+		{"NoPermissions", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			token, _ := auth.jwt.GenerateJWT(&security.Claims{UserID: 1, Permissions: tt.Permissions})
+
+			req, _ := http.NewRequest("GET", "/endpoint", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.ExpectedCode {
+				t.Errorf("expected code %d, got %d instead", tt.ExpectedCode, w.Code)
+			}
+		})
+	}
+}
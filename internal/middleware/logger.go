@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveQueryParams are query parameters carrying secrets, such as
+// confirmation codes and tokens from emailed links
+var sensitiveQueryParams = []string{"code", "token", "mfa_token", "refresh_token"}
+
+// sensitivePathPrefixes are routes with secret as the last path segment
+var sensitivePathPrefixes = []string{"/authorize/email/challenge/"}
+
+// Logger is gin.Logger which replaces secrets in request path and query
+// with placeholder, so that they don't end up in access logs
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(redactedLogFormatter)
+}
+
+// redactedLogFormatter is gin default log format applied to redacted path
+func redactedLogFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency - param.Latency%time.Second
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactPath(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// redactPath replaces secrets in request path with optional query string
+func redactPath(path string) string {
+	rawQuery := ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+
+	for _, prefix := range sensitivePathPrefixes {
+		if i := strings.Index(path, prefix); i >= 0 && len(path) > i+len(prefix) {
+			path = path[:i+len(prefix)] + redacted
+		}
+	}
+
+	if rawQuery == "" {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return path + "?" + redacted
+	}
+
+	for _, param := range sensitiveQueryParams {
+		if _, ok := query[param]; ok {
+			query[param] = []string{redacted}
+		}
+	}
+
+	return path + "?" + query.Encode()
+}
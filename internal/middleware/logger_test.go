@@ -0,0 +1,25 @@
+package middleware
+
+import "testing"
+
+func TestRedactPath(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Path     string
+		Expected string
+	}{
+		{"NoSecrets", "/api/user/1?fields=name", "/api/user/1?fields=name"},
+		{"ChallengeCode", "/api/authorize/email/challenge/abcdef", "/api/authorize/email/challenge/REDACTED"},
+		{"QueryCode", "/api/authorize/email/confirm?code=abcdef", "/api/authorize/email/confirm?code=REDACTED"},
+		{"QueryToken", "/authorize/magic-link?lang=en&token=abc", "/authorize/magic-link?lang=en&token=REDACTED"},
+		{"MalformedQuery", "/api/authorize/email/confirm?code=%zz", "/api/authorize/email/confirm?REDACTED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			if redactedPath := redactPath(tt.Path); redactedPath != tt.Expected {
+				t.Errorf("expected %q, got %q", tt.Expected, redactedPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/Hickar/gin-rush/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc returns identifier requests are counted by
+type KeyFunc func(c *gin.Context) string
+
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser counts requests by ID of user set by JWT middleware, falling
+// back to client IP for unauthenticated requests
+func KeyByUser(c *gin.Context) string {
+	if userID := c.GetUint("user_id"); userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey counts requests by API key passed in header, falling back to
+// client IP if header is empty
+func KeyByAPIKey(header string) KeyFunc {
+	return func(c *gin.Context) string {
+		if key := c.GetHeader(header); key != "" {
+			return "api_key:" + key
+		}
+
+		return KeyByIP(c)
+	}
+}
+
+// RateLimit rejects requests exceeding limit with 429 status. Limits of
+// different groups are counted separately, state of the window is reported
+// in RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+func RateLimit(limiter ratelimit.Limiter, group string, limit ratelimit.Limit, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, err := limiter.Allow(c.Request.Context(), group+":"+key(c), limit)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", formatSeconds(res.Reset))
+
+		if !res.Allowed {
+			c.Header("Retry-After", formatSeconds(res.RetryAfter))
+			c.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitGroup builds RateLimit middleware from configuration of group.
+// Requests of group without configured limit are passed through.
+func RateLimitGroup(limiter ratelimit.Limiter, conf *config.RateLimitConfig, group string) (gin.HandlerFunc, error) {
+	rule, ok := conf.Groups[group]
+	if !ok || rule.Requests <= 0 {
+		return func(c *gin.Context) { c.Next() }, nil
+	}
+
+	var key KeyFunc
+	switch rule.KeyBy {
+	case "ip", "":
+		key = KeyByIP
+	case "user":
+		key = KeyByUser
+	case "api_key":
+		key = KeyByAPIKey(conf.APIKeyHeader)
+	default:
+		return nil, fmt.Errorf("unknown rate limit key %q for group %q", rule.KeyBy, group)
+	}
+
+	limit := ratelimit.Limit{Requests: rule.Requests, Window: time.Duration(rule.Window) * time.Second}
+	return RateLimit(limiter, group, limit, key), nil
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hickar/gin-rush/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limit := ratelimit.Limit{Requests: 2, Window: time.Minute}
+	r := gin.New()
+	r.Use(RateLimit(ratelimit.NewMemoryLimiter(), "test", limit, KeyByAPIKey("X-API-Key")))
+	r.GET("/endpoint", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/endpoint", nil)
+		req.Header.Set("X-API-Key", apiKey)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("UnderLimit", func(t *testing.T) {
+		for i := 0; i < limit.Requests; i++ {
+			w := request("first")
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected code %d, got %d instead", http.StatusOK, w.Code)
+			}
+
+			if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") == "" {
+				t.Errorf("unexpected rate limit headers: %v", w.Header())
+			}
+		}
+	})
+
+	t.Run("OverLimit", func(t *testing.T) {
+		w := request("first")
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected code %d, got %d instead", http.StatusTooManyRequests, w.Code)
+		}
+
+		if w.Header().Get("Retry-After") == "" || w.Header().Get("RateLimit-Remaining") != "0" {
+			t.Errorf("unexpected rate limit headers: %v", w.Header())
+		}
+	})
+
+	t.Run("AnotherKey", func(t *testing.T) {
+		if w := request("second"); w.Code != http.StatusOK {
+			t.Errorf("expected code %d, got %d instead", http.StatusOK, w.Code)
+		}
+	})
+}
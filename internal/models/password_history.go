@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// PasswordHistory is a hash of password previously set by the user, kept
+// to prevent its reuse. Salt is set only for legacy scrypt hashes. Entries
+// are removed together with the user they belong to.
+type PasswordHistory struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index"`
+	User     User   `gorm:"constraint:OnDelete:CASCADE"`
+	Password []byte `gorm:"type:varbinary(255);not null"`
+	Salt     []byte `gorm:"type:varbinary(16)"`
+}
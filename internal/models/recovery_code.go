@@ -0,0 +1,16 @@
+package models
+
+import (
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a one-time code which replaces TOTP code when
+// authenticator device is lost. Only hash of the code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index"`
+	CodeHash string `gorm:"type:varchar(64);not null"`
+	UsedAt   sql.NullTime
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Built-in roles
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+)
+
+// Permissions checked by routes
+const (
+	PermissionReadUsers   = "users:read"
+	PermissionDeleteUsers = "users:delete"
+	PermissionManageUsers = "users:manage"
+)
+
+// DefaultRolePermissions is used unless roles are set in configuration
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin:     {PermissionReadUsers, PermissionDeleteUsers, PermissionManageUsers},
+	RoleModerator: {PermissionReadUsers},
+}
+
+// UserRole grants role to the user
+type UserRole struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_user_roles_user_role"`
+	Role      string `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_roles_user_role"`
+	CreatedAt time.Time
+}
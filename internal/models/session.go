@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Session is a login of the user on some device. Its ID is shared by the
+// refresh token family and access tokens issued within the login.
+type Session struct {
+	ID         string    `gorm:"type:varchar(64);primaryKey"`
+	UserID     uint      `gorm:"not null;index"`
+	UserAgent  string    `gorm:"type:varchar(512)"`
+	IP         string    `gorm:"type:varchar(45)"`
+	CreatedAt  time.Time `gorm:"not null"`
+	LastSeenAt time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+}
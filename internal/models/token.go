@@ -0,0 +1,9 @@
+package models
+
+// RefreshToken is a server-side record of issued refresh token.
+// Tokens issued by rotating each other share the same Family.
+type RefreshToken struct {
+	UserID uint
+	Family string
+	Used   bool
+}
@@ -8,13 +8,23 @@ import (
 
 type User struct {
 	gorm.Model
-	Name             string `gorm:"not null"`
-	Email            string `gorm:"not null;unique"`
-	Password         []byte `gorm:"type:varbinary(32);not null"`
-	Salt             []byte `gorm:"type:varbinary(16);not null"`
-	Bio              sql.NullString
-	Avatar           sql.NullString
-	BirthDate        sql.NullTime
-	Enabled          bool   `gorm:"default:false"`
-	ConfirmationCode string `gorm:"type:varchar(255);not null;unique"`
-}
\ No newline at end of file
+	Name                  string `gorm:"not null"`
+	Email                 string `gorm:"not null;unique"`
+	Password              []byte `gorm:"type:varbinary(255);not null"`
+	Salt                  []byte `gorm:"type:varbinary(16)"`
+	Bio                   sql.NullString
+	Avatar                sql.NullString
+	BirthDate             sql.NullTime
+	Enabled               bool           `gorm:"default:false"`
+	ConfirmationCode      sql.NullString `gorm:"type:varchar(255);unique"`
+	ConfirmationExpiresAt sql.NullTime
+	MFAEnabled            bool   `gorm:"default:false"`
+	MFASecret             []byte `gorm:"type:varbinary(64)"`
+	MFALastStep           int64  `gorm:"default:0"`
+	EmailMFAEnabled       bool   `gorm:"default:false"`
+	PendingEmail          sql.NullString
+	SuspendedAt           sql.NullTime
+	SuspendedUntil        sql.NullTime
+	SuspendedBy           sql.NullInt64
+	SuspensionReason      sql.NullString `gorm:"type:varchar(512)"`
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Hickar/gin-rush/internal/models"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FindUsers returns page of users whose name or email contains query, along
+// with total number of matching users. Soft-deleted users are included only
+// if withDeleted is set.
+func (r *UserRepository) FindUsers(query string, withDeleted bool, offset, limit int) ([]models.User, int64, error) {
+	db := r.db.Model(&models.User{})
+	if withDeleted {
+		db = db.Unscoped()
+	}
+
+	if query != "" {
+		pattern := "%" + likeEscaper.Replace(query) + "%"
+		db = db.Where("name LIKE ? OR email LIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := db.Order("id").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// FindUserByIDWithDeleted looks user up by ID including soft-deleted ones
+func (r *UserRepository) FindUserByIDWithDeleted(id uint) (*models.User, error) {
+	var user models.User
+	return &user, r.db.Unscoped().First(&user, id).Error
+}
+
+// RestoreUser undoes soft deletion of user
+func (r *UserRepository) RestoreUser(user *models.User) error {
+	err := r.db.Unscoped().Model(user).Update("deleted_at", nil).Error
+	if err != nil {
+		return err
+	}
+
+	cacheKey := fmt.Sprintf("users:%d", user.ID)
+	return r.cache.Del(context.Background(), cacheKey).Err()
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/go-redis/redis/v8"
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+
+type AuthRepository struct {
+	cache *redis.Client
+}
+
+func NewAuthRepository(cache *redis.Client) *AuthRepository {
+	return &AuthRepository{cache: cache}
+}
+
+func refreshTokenKey(hash string) string {
+	return fmt.Sprintf("refresh_tokens:%s", hash)
+}
+
+func refreshFamilyKey(family string) string {
+	return fmt.Sprintf("refresh_families:%s", family)
+}
+
+func userRefreshFamiliesKey(userID uint) string {
+	return fmt.Sprintf("users:%d:refresh_families", userID)
+}
+
+func revokedTokenKey(jti string) string {
+	return fmt.Sprintf("revoked_tokens:%s", jti)
+}
+
+func userTokensRevokedAtKey(userID uint) string {
+	return fmt.Sprintf("users:%d:tokens_revoked_at", userID)
+}
+
+func emailOTPKey(challenge string) string {
+	return fmt.Sprintf("email_otp:%s", challenge)
+}
+
+func oneTimeTokenKey(purpose, hash string) string {
+	return fmt.Sprintf("one_time_tokens:%s:%s", purpose, hash)
+}
+
+func userOneTimeTokensKey(userID uint, purpose string) string {
+	return fmt.Sprintf("users:%d:one_time_tokens:%s", userID, purpose)
+}
+
+// CreateRefreshToken stores refresh token under its hash and prolongs
+// lifetime of the family token belongs to
+func (r *AuthRepository) CreateRefreshToken(hash string, token *models.RefreshToken, ttl time.Duration) error {
+	ctx := context.Background()
+	key := refreshTokenKey(hash)
+
+	_, err := r.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "user_id", token.UserID, "family", token.Family, "uses", 0)
+		pipe.Expire(ctx, key, ttl)
+		pipe.Set(ctx, refreshFamilyKey(token.Family), token.UserID, ttl)
+		pipe.SAdd(ctx, userRefreshFamiliesKey(token.UserID), token.Family)
+		pipe.Expire(ctx, userRefreshFamiliesKey(token.UserID), ttl)
+		return nil
+	})
+
+	return err
+}
+
+// useRefreshTokenScript increments token usage counter only if token record
+// still exists, so expired tokens are not resurrected without TTL
+var useRefreshTokenScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+redis.call("HINCRBY", KEYS[1], "uses", 1)
+return redis.call("HMGET", KEYS[1], "user_id", "family", "uses")
+`)
+
+// UseRefreshToken atomically marks refresh token as used. Returned token has
+// Used flag set if it was already used before this call.
+func (r *AuthRepository) UseRefreshToken(hash string) (*models.RefreshToken, error) {
+	res, err := useRefreshTokenScript.Run(context.Background(), r.cache, []string{refreshTokenKey(hash)}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	fields, ok := res.([]interface{})
+	if !ok || len(fields) != 3 {
+		return nil, errors.New("malformed refresh token record")
+	}
+
+	userID, _ := fields[0].(string)
+	family, _ := fields[1].(string)
+	usesStr, _ := fields[2].(string)
+
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed refresh token record: %w", err)
+	}
+
+	uses, err := strconv.Atoi(usesStr)
+	if err != nil {
+		return nil, fmt.Errorf("malformed refresh token record: %w", err)
+	}
+
+	return &models.RefreshToken{
+		UserID: uint(id),
+		Family: family,
+		Used:   uses > 1,
+	}, nil
+}
+
+func (r *AuthRepository) RefreshFamilyExists(family string) (bool, error) {
+	n, err := r.cache.Exists(context.Background(), refreshFamilyKey(family)).Result()
+	return n > 0, err
+}
+
+// RevokeRefreshFamily invalidates every refresh token issued within family
+func (r *AuthRepository) RevokeRefreshFamily(family string) error {
+	return r.cache.Del(context.Background(), refreshFamilyKey(family)).Err()
+}
+
+// RevokeUserRefreshFamilies invalidates every refresh token issued to user
+func (r *AuthRepository) RevokeUserRefreshFamilies(userID uint) error {
+	ctx := context.Background()
+	key := userRefreshFamiliesKey(userID)
+
+	families, err := r.cache.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+	for _, family := range families {
+		keys = append(keys, refreshFamilyKey(family))
+	}
+
+	return r.cache.Del(ctx, keys...).Err()
+}
+
+// RevokeAccessToken puts JWT ID on denylist until token expires by itself
+func (r *AuthRepository) RevokeAccessToken(jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return r.cache.Set(context.Background(), revokedTokenKey(jti), 1, ttl).Err()
+}
+
+// RevokeUserAccessTokens rejects every user JWT issued up to this moment.
+// Marker lives as long as the longest-living token could. Moment is stored
+// in milliseconds, so that tokens issued right after revocation, such as on
+// login following password reset, are still accepted.
+func (r *AuthRepository) RevokeUserAccessTokens(userID uint, ttl time.Duration) error {
+	return r.cache.Set(context.Background(), userTokensRevokedAtKey(userID), time.Now().UnixMilli(), ttl).Err()
+}
+
+// AccessTokenRevoked reports whether JWT was revoked either by its ID or by
+// revocation of all tokens of the user issued before issuedAt, given in
+// milliseconds
+func (r *AuthRepository) AccessTokenRevoked(jti string, userID uint, issuedAt int64) (bool, error) {
+	ctx := context.Background()
+
+	var denied *redis.IntCmd
+	var revokedAt *redis.StringCmd
+
+	_, err := r.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		denied = pipe.Exists(ctx, revokedTokenKey(jti))
+		revokedAt = pipe.Get(ctx, userTokensRevokedAtKey(userID))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if denied.Val() > 0 {
+		return true, nil
+	}
+
+	cutoff, err := revokedAt.Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return issuedAt <= cutoff, nil
+}
+
+// CreateEmailOTP stores hash of one-time code sent by email for challenge
+func (r *AuthRepository) CreateEmailOTP(challenge, hash string, ttl time.Duration) error {
+	ctx := context.Background()
+	key := emailOTPKey(challenge)
+
+	_, err := r.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "hash", hash, "attempts", 0)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+
+	return err
+}
+
+// verifyEmailOTPScript counts attempt and compares code hash. Code is
+// removed once it matches or attempts limit is exceeded.
+var verifyEmailOTPScript = redis.NewScript(`
+local hash = redis.call("HGET", KEYS[1], "hash")
+if not hash then
+	return -1
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts > tonumber(ARGV[2]) then
+	redis.call("DEL", KEYS[1])
+	return -1
+end
+if hash == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 1
+end
+return 0
+`)
+
+// VerifyEmailOTP reports whether code hash matches the one stored for
+// challenge. ErrTokenNotFound is returned if there is no code to check
+// against anymore.
+func (r *AuthRepository) VerifyEmailOTP(challenge, hash string, maxAttempts int) (bool, error) {
+	res, err := verifyEmailOTPScript.Run(context.Background(), r.cache, []string{emailOTPKey(challenge)}, hash, maxAttempts).Int()
+	if err != nil {
+		return false, err
+	}
+
+	if res < 0 {
+		return false, ErrTokenNotFound
+	}
+
+	return res == 1, nil
+}
+
+// CreateOneTimeToken stores hash of single-use token sent to user, such as
+// magic link. Purpose separates tokens of different kinds from each other.
+func (r *AuthRepository) CreateOneTimeToken(purpose, hash string, userID uint, ttl time.Duration) error {
+	ctx := context.Background()
+	userKey := userOneTimeTokensKey(userID, purpose)
+
+	_, err := r.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, oneTimeTokenKey(purpose, hash), userID, ttl)
+		pipe.SAdd(ctx, userKey, hash)
+		pipe.Expire(ctx, userKey, ttl)
+		return nil
+	})
+
+	return err
+}
+
+// FindOneTimeToken returns ID of user token with given hash was issued to,
+// leaving token intact
+func (r *AuthRepository) FindOneTimeToken(purpose, hash string) (uint, error) {
+	userID, err := r.cache.Get(context.Background(), oneTimeTokenKey(purpose, hash)).Uint64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, ErrTokenNotFound
+		}
+		return 0, err
+	}
+
+	return uint(userID), nil
+}
+
+// UseOneTimeToken removes token with given hash and returns ID of user it was
+// issued to. ErrTokenNotFound is returned for expired or already used token.
+func (r *AuthRepository) UseOneTimeToken(purpose, hash string) (uint, error) {
+	userID, err := r.cache.GetDel(context.Background(), oneTimeTokenKey(purpose, hash)).Uint64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, ErrTokenNotFound
+		}
+		return 0, err
+	}
+
+	return uint(userID), nil
+}
+
+// RevokeUserOneTimeTokens removes every not yet used token with given
+// purpose issued to user
+func (r *AuthRepository) RevokeUserOneTimeTokens(userID uint, purpose string) error {
+	ctx := context.Background()
+	userKey := userOneTimeTokensKey(userID, purpose)
+
+	hashes, err := r.cache.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userKey}
+	for _, hash := range hashes {
+		keys = append(keys, oneTimeTokenKey(purpose, hash))
+	}
+
+	return r.cache.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Kinds of login blocks. Delay is a short pause growing with each failed
+// attempt, lockout is a long one applied after too many failures in a row.
+const (
+	LoginDelay   = "delay"
+	LoginLockout = "lockout"
+)
+
+func loginFailuresKey(subject string) string {
+	return fmt.Sprintf("login_failures:%s", subject)
+}
+
+func loginBlockKey(kind, subject string) string {
+	return fmt.Sprintf("login_blocks:%s:%s", kind, subject)
+}
+
+var recordLoginFailureScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RecordLoginFailure increments failed login attempts counter of subject,
+// such as account or client IP, and returns its new value. Counter is reset
+// after window passes since the first failure.
+func (r *AuthRepository) RecordLoginFailure(subject string, window time.Duration) (int64, error) {
+	return recordLoginFailureScript.Run(context.Background(), r.cache, []string{loginFailuresKey(subject)}, window.Milliseconds()).Int64()
+}
+
+// ResetLoginFailures clears failed attempts counter and blocks of subject
+func (r *AuthRepository) ResetLoginFailures(subject string) error {
+	return r.cache.Del(context.Background(),
+		loginFailuresKey(subject),
+		loginBlockKey(LoginDelay, subject),
+		loginBlockKey(LoginLockout, subject),
+	).Err()
+}
+
+// BlockLogin forbids login attempts of subject for given duration
+func (r *AuthRepository) BlockLogin(kind, subject string, ttl time.Duration) error {
+	return r.cache.Set(context.Background(), loginBlockKey(kind, subject), 1, ttl).Err()
+}
+
+// LoginBlockedFor returns how long login attempts of subject stay blocked,
+// zero if they are allowed
+func (r *AuthRepository) LoginBlockedFor(kind, subject string) (time.Duration, error) {
+	ttl, err := r.cache.PTTL(context.Background(), loginBlockKey(kind, subject)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func cooldownKey(name string) string {
+	return fmt.Sprintf("cooldowns:%s", name)
+}
+
+// AcquireCooldown starts cooldown with given name unless it's already
+// running, in which case time left until its end is returned
+func (r *AuthRepository) AcquireCooldown(name string, ttl time.Duration) (time.Duration, error) {
+	ctx := context.Background()
+
+	acquired, err := r.cache.SetNX(ctx, cooldownKey(name), 1, ttl).Result()
+	if err != nil || acquired {
+		return 0, err
+	}
+
+	left, err := r.cache.PTTL(ctx, cooldownKey(name)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if left < 0 {
+		return 0, nil
+	}
+
+	return left, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"github.com/Hickar/gin-rush/internal/models"
+	"gorm.io/gorm"
+)
+
+// FindPasswordHistory returns up to limit latest previous passwords of the user
+func (r *UserRepository) FindPasswordHistory(userID uint, limit int) ([]models.PasswordHistory, error) {
+	var history []models.PasswordHistory
+
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&history).Error
+	return history, err
+}
+
+// AddPasswordHistory records previous password of the user, keeping only
+// given number of latest entries
+func (r *UserRepository) AddPasswordHistory(entry *models.PasswordHistory, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+
+		var ids []uint
+		err := tx.Model(&models.PasswordHistory{}).
+			Where("user_id = ?", entry.UserID).
+			Order("id DESC").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) <= keep {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&models.PasswordHistory{}, ids[keep:]).Error
+	})
+}
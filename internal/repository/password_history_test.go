@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/cache"
+	"github.com/Hickar/gin-rush/internal/dbtest"
+	"github.com/Hickar/gin-rush/internal/models"
+)
+
+func TestAddPasswordHistory(t *testing.T) {
+	tests := []struct {
+		Name    string
+		IDs     []uint
+		Deleted []uint
+	}{
+		{"WithinLimitShouldKeepAll", []uint{3, 2, 1}, nil},
+		{"BeyondLimitShouldPruneOldest", []uint{6, 5, 4, 3, 2, 1}, []uint{2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			db, dbMock := dbtest.NewMockDB(t)
+			client, _ := cache.NewCacheMock()
+			repo := NewUserRepository(db, client)
+
+			rows := sqlmock.NewRows([]string{"id"})
+			for _, id := range tt.IDs {
+				rows.AddRow(id)
+			}
+
+			dbMock.ExpectBegin()
+			dbMock.ExpectExec("INSERT INTO `password_histories`").WillReturnResult(sqlmock.NewResult(int64(tt.IDs[0]), 1))
+			dbMock.ExpectQuery("SELECT `id` FROM `password_histories`").WithArgs(1).WillReturnRows(rows)
+			if tt.Deleted != nil {
+				dbMock.ExpectExec("DELETE FROM `password_histories`").
+					WithArgs(tt.Deleted[0], tt.Deleted[1]).
+					WillReturnResult(sqlmock.NewResult(0, int64(len(tt.Deleted))))
+			}
+			dbMock.ExpectCommit()
+
+			entry := &models.PasswordHistory{UserID: 1, Password: []byte("hash")}
+			if err := repo.AddPasswordHistory(entry, 4); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Errorf("some of DB expectations were not met: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReplaceRecoveryCodes drops all recovery codes of the user in favor of new ones
+func (r *UserRepository) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		if len(hashes) == 0 {
+			return nil
+		}
+
+		codes := make([]models.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode marks matching unused code as used, reporting whether
+// such code was found
+func (r *UserRepository) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	res := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", sql.NullTime{Time: time.Now(), Valid: true})
+
+	return res.RowsAffected == 1, res.Error
+}
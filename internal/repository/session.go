@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Hickar/gin-rush/internal/models"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionRepository stores user sessions either in Redis or in SQL
+// database. Expired sessions are never returned.
+type SessionRepository interface {
+	CreateSession(session *models.Session) error
+	FindSession(id string) (*models.Session, error)
+	FindUserSessions(userID uint) ([]models.Session, error)
+	// TouchSession saves last seen time, IP and expiration time of existing
+	// session, returning ErrSessionNotFound if it's gone
+	TouchSession(session *models.Session) error
+	DeleteSession(userID uint, id string) error
+	DeleteUserSessions(userID uint) error
+}
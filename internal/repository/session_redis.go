@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/go-redis/redis/v8"
+)
+
+type RedisSessionRepository struct {
+	cache *redis.Client
+}
+
+func NewRedisSessionRepository(cache *redis.Client) *RedisSessionRepository {
+	return &RedisSessionRepository{cache: cache}
+}
+
+func sessionKey(id string) string {
+	return fmt.Sprintf("sessions:%s", id)
+}
+
+func userSessionsKey(userID uint) string {
+	return fmt.Sprintf("users:%d:sessions", userID)
+}
+
+// CreateSession stores session as hash expiring together with the session.
+// Set of user sessions lives as long as the latest of them.
+func (r *RedisSessionRepository) CreateSession(session *models.Session) error {
+	ctx := context.Background()
+	key := sessionKey(session.ID)
+
+	_, err := r.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"user_id", session.UserID,
+			"user_agent", session.UserAgent,
+			"ip", session.IP,
+			"created_at", session.CreatedAt.Unix(),
+			"last_seen_at", session.LastSeenAt.Unix(),
+			"expires_at", session.ExpiresAt.Unix(),
+		)
+		pipe.ExpireAt(ctx, key, session.ExpiresAt)
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+		pipe.ExpireAt(ctx, userSessionsKey(session.UserID), session.ExpiresAt)
+		return nil
+	})
+
+	return err
+}
+
+func (r *RedisSessionRepository) FindSession(id string) (*models.Session, error) {
+	fields, err := r.cache.HGetAll(context.Background(), sessionKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSession(id, fields)
+}
+
+// FindUserSessions returns sessions of the user, most recently used first.
+// IDs of expired sessions are removed from the set of user sessions.
+func (r *RedisSessionRepository) FindUserSessions(userID uint) ([]models.Session, error) {
+	ctx := context.Background()
+
+	ids, err := r.cache.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	_, err = r.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, sessionKey(id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []models.Session
+	var expired []interface{}
+
+	for i, cmd := range cmds {
+		session, err := parseSession(ids[i], cmd.Val())
+		if err != nil {
+			expired = append(expired, ids[i])
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if len(expired) > 0 {
+		if err := r.cache.SRem(ctx, userSessionsKey(userID), expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// touchSessionScript updates session only if it still exists, so that
+// deleted session is not resurrected as incomplete hash
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], "ip", ARGV[1], "last_seen_at", ARGV[2], "expires_at", ARGV[3])
+redis.call("EXPIREAT", KEYS[1], ARGV[3])
+if redis.call("TTL", KEYS[2]) < tonumber(ARGV[3]) - tonumber(ARGV[2]) then
+	redis.call("EXPIREAT", KEYS[2], ARGV[3])
+end
+return 1
+`)
+
+func (r *RedisSessionRepository) TouchSession(session *models.Session) error {
+	keys := []string{sessionKey(session.ID), userSessionsKey(session.UserID)}
+
+	touched, err := touchSessionScript.Run(context.Background(), r.cache, keys,
+		session.IP, session.LastSeenAt.Unix(), session.ExpiresAt.Unix()).Int64()
+	if err != nil {
+		return err
+	}
+
+	if touched == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteSession removes session together with its ID in the set of user
+// sessions
+func (r *RedisSessionRepository) DeleteSession(userID uint, id string) error {
+	ctx := context.Background()
+
+	_, err := r.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(id))
+		pipe.SRem(ctx, userSessionsKey(userID), id)
+		return nil
+	})
+
+	return err
+}
+
+func (r *RedisSessionRepository) DeleteUserSessions(userID uint) error {
+	ctx := context.Background()
+	key := userSessionsKey(userID)
+
+	ids, err := r.cache.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+
+	return r.cache.Del(ctx, keys...).Err()
+}
+
+// parseSession builds session from hash fields, reporting missing hash as
+// ErrSessionNotFound
+func parseSession(id string, fields map[string]string) (*models.Session, error) {
+	if len(fields) == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	var timestamps [3]int64
+	for i, name := range []string{"created_at", "last_seen_at", "expires_at"} {
+		value, err := strconv.ParseInt(fields[name], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed session record: %w", err)
+		}
+		timestamps[i] = value
+	}
+
+	userID, err := strconv.ParseUint(fields["user_id"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed session record: %w", err)
+	}
+
+	return &models.Session{
+		ID:         id,
+		UserID:     uint(userID),
+		UserAgent:  fields["user_agent"],
+		IP:         fields["ip"],
+		CreatedAt:  time.Unix(timestamps[0], 0),
+		LastSeenAt: time.Unix(timestamps[1], 0),
+		ExpiresAt:  time.Unix(timestamps[2], 0),
+	}, nil
+}
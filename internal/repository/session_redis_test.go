@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/Hickar/gin-rush/internal/cache"
+)
+
+func TestRedisDeleteSession(t *testing.T) {
+	client, cacheMock := cache.NewCacheMock()
+	repo := NewRedisSessionRepository(client)
+
+	cacheMock.ExpectTxPipeline()
+	cacheMock.ExpectDel("sessions:abc").SetVal(1)
+	cacheMock.ExpectSRem("users:1:sessions", "abc").SetVal(1)
+	cacheMock.ExpectTxPipelineExec()
+
+	if err := repo.DeleteSession(1, "abc"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := cacheMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of Redis expectations were not met: %s", err)
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/pkg/database"
+	"gorm.io/gorm"
+)
+
+type SQLSessionRepository struct {
+	db *database.Database
+}
+
+func NewSQLSessionRepository(db *database.Database) *SQLSessionRepository {
+	return &SQLSessionRepository{db: db}
+}
+
+func (r *SQLSessionRepository) CreateSession(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *SQLSessionRepository) FindSession(id string) (*models.Session, error) {
+	var session models.Session
+
+	err := r.db.Where("id = ? AND expires_at > ?", id, time.Now()).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+
+	return &session, err
+}
+
+// FindUserSessions returns sessions of the user, most recently used first.
+// Expired sessions of the user are deleted along the way.
+func (r *SQLSessionRepository) FindUserSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	now := time.Now()
+
+	if err := r.db.Where("user_id = ? AND expires_at <= ?", userID, now).Delete(&models.Session{}).Error; err != nil {
+		return nil, err
+	}
+
+	err := r.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *SQLSessionRepository) TouchSession(session *models.Session) error {
+	res := r.db.Model(&models.Session{}).
+		Where("id = ? AND expires_at > ?", session.ID, time.Now()).
+		Updates(map[string]interface{}{
+			"ip":           session.IP,
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLSessionRepository) DeleteSession(userID uint, id string) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Session{}).Error
+}
+
+func (r *SQLSessionRepository) DeleteUserSessions(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/dbtest"
+	"github.com/Hickar/gin-rush/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestSQLFindSession(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Err    error
+		Result error
+	}{
+		{"ExistingSessionShouldBeFound", nil, nil},
+		{"MissingSessionShouldFail", gorm.ErrRecordNotFound, ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			db, dbMock := dbtest.NewMockDB(t)
+			repo := NewSQLSessionRepository(db)
+
+			query := dbMock.ExpectQuery("SELECT (.+) FROM `sessions` WHERE id = (.+) AND expires_at > (.+)").
+				WithArgs("abc", sqlmock.AnyArg())
+			if tt.Err != nil {
+				query.WillReturnError(tt.Err)
+			} else {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow("abc", 1))
+			}
+
+			session, err := repo.FindSession("abc")
+			if !errors.Is(err, tt.Result) {
+				t.Errorf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			if err == nil && (session.ID != "abc" || session.UserID != 1) {
+				t.Errorf("unexpected session %+v", session)
+			}
+
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Errorf("some of DB expectations were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestSQLFindUserSessions(t *testing.T) {
+	db, dbMock := dbtest.NewMockDB(t)
+	repo := NewSQLSessionRepository(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "user_id", "last_seen_at"}).
+		AddRow("recent", 1, now).
+		AddRow("older", 1, now.Add(-time.Hour))
+
+	dbMock.ExpectExec("DELETE FROM `sessions` WHERE user_id = (.+) AND expires_at <= (.+)").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery("SELECT (.+) FROM `sessions` WHERE user_id = (.+) ORDER BY last_seen_at DESC").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	sessions, err := repo.FindUserSessions(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(sessions) != 2 || sessions[0].ID != "recent" {
+		t.Errorf("unexpected sessions %+v", sessions)
+	}
+
+	if err := dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of DB expectations were not met: %s", err)
+	}
+}
+
+func TestSQLTouchSession(t *testing.T) {
+	tests := []struct {
+		Name         string
+		RowsAffected int64
+		Result       error
+	}{
+		{"ExistingSessionShouldBeTouched", 1, nil},
+		{"MissingSessionShouldFail", 0, ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			db, dbMock := dbtest.NewMockDB(t)
+			repo := NewSQLSessionRepository(db)
+
+			now := time.Now()
+			session := &models.Session{ID: "abc", UserID: 1, IP: "127.0.0.1", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+
+			dbMock.ExpectExec("UPDATE `sessions` SET (.+) WHERE id = (.+) AND expires_at > (.+)").
+				WithArgs(session.ExpiresAt, session.IP, session.LastSeenAt, "abc", sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, tt.RowsAffected))
+
+			if err := repo.TouchSession(session); !errors.Is(err, tt.Result) {
+				t.Errorf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Errorf("some of DB expectations were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestSQLDeleteSession(t *testing.T) {
+	db, dbMock := dbtest.NewMockDB(t)
+	repo := NewSQLSessionRepository(db)
+
+	dbMock.ExpectExec("DELETE FROM `sessions` WHERE id = (.+) AND user_id = (.+)").
+		WithArgs("abc", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.DeleteSession(1, "abc"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of DB expectations were not met: %s", err)
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func userSuspendedKey(userID uint) string {
+	return fmt.Sprintf("users:%d:suspended_until", userID)
+}
+
+// MarkUserSuspended makes access tokens of user rejected for given duration.
+// Until is kept to be reported with rejection, zero means indefinite
+// suspension.
+func (r *AuthRepository) MarkUserSuspended(userID uint, until time.Time, ttl time.Duration) error {
+	var value int64
+	if !until.IsZero() {
+		value = until.Unix()
+	}
+
+	return r.cache.Set(context.Background(), userSuspendedKey(userID), value, ttl).Err()
+}
+
+// UnmarkUserSuspended makes access tokens of user accepted again
+func (r *AuthRepository) UnmarkUserSuspended(userID uint) error {
+	return r.cache.Del(context.Background(), userSuspendedKey(userID)).Err()
+}
+
+// UserSuspended reports whether user is marked suspended and until when
+func (r *AuthRepository) UserSuspended(userID uint) (bool, time.Time, error) {
+	value, err := r.cache.Get(context.Background(), userSuspendedKey(userID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, time.Time{}, nil
+		}
+		return false, time.Time{}, err
+	}
+
+	if value == 0 {
+		return true, time.Time{}, nil
+	}
+
+	return true, time.Unix(value, 0), nil
+}
@@ -8,8 +8,21 @@ import (
 	"github.com/Hickar/gin-rush/internal/models"
 	"github.com/Hickar/gin-rush/pkg/database"
 	"github.com/go-redis/redis/v8"
+	"github.com/go-sql-driver/mysql"
 )
 
+// ErrDuplicateKey is returned when record conflicts with existing one on
+// unique column, such as email
+var ErrDuplicateKey = errors.New("record with such unique key already exists")
+
+// mysqlDuplicateEntry is MySQL error number of unique index violation
+const mysqlDuplicateEntry = 1062
+
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
 type UserRepository struct {
 	db *database.Database
 	cache *redis.Client
@@ -20,7 +33,12 @@ func NewUserRepository(db *database.Database, cache *redis.Client) *UserReposito
 }
 
 func (r *UserRepository) CreateUser(user *models.User) error {
-	return r.db.Create(user).Error
+	err := r.db.Create(user).Error
+	if isDuplicateKey(err) {
+		return ErrDuplicateKey
+	}
+
+	return err
 }
 
 func (r *UserRepository) UserWithEmailExists(email string) (bool, error) {
@@ -40,6 +58,9 @@ func (r *UserRepository) FindUserByID(id uint) (*models.User, error) {
 func (r *UserRepository) UpdateUser(user *models.User) error {
 	err := r.db.Save(user).Error
 	if err != nil {
+		if isDuplicateKey(err) {
+			return ErrDuplicateKey
+		}
 		return errors.New("unable to update user record in database")
 	}
 
@@ -52,20 +73,25 @@ func (r *UserRepository) GetUserByID(id uint) (*models.User, error) {
 	return &user, r.db.FindBy(&user, "id", id)
 }
 
-func (r *UserRepository) EnableUserByCode(code string) (*models.User, error) {
-	var user models.User
-
-	err := r.db.FindBy(&user, "confirmation_code", code)
+// UpdateConfirmationCode saves only email confirmation code of the user and
+// its expiry, so that other columns changed since the user was loaded are
+// not overwritten
+func (r *UserRepository) UpdateConfirmationCode(user *models.User) error {
+	err := r.db.Model(user).
+		Select("confirmation_code", "confirmation_expires_at").
+		Updates(user).Error
 	if err != nil {
-		return &user, err
+		return err
 	}
 
-	if user.Enabled {
-		return &user, nil
-	}
+	cacheKey := fmt.Sprintf("users:%d", user.ID)
+	return r.cache.Del(context.Background(), cacheKey).Err()
+}
 
-	user.Enabled = true
-	return &user, r.db.Save(&user).Error
+// FindUserByConfirmationCode looks user up by hash of email confirmation code
+func (r *UserRepository) FindUserByConfirmationCode(hash string) (*models.User, error) {
+	var user models.User
+	return &user, r.db.FindBy(&user, "confirmation_code", hash)
 }
 
 func (r *UserRepository) DeleteUser(user *models.User) error {
@@ -0,0 +1,19 @@
+package repository
+
+import "github.com/Hickar/gin-rush/internal/models"
+
+// FindUserRoles returns names of roles granted to the user
+func (r *UserRepository) FindUserRoles(userID uint) ([]string, error) {
+	var roles []string
+	err := r.db.Model(&models.UserRole{}).Where("user_id = ?", userID).Order("role").Pluck("role", &roles).Error
+	return roles, err
+}
+
+// AddUserRole grants role to the user, doing nothing if it's already granted
+func (r *UserRepository) AddUserRole(userID uint, role string) error {
+	return r.db.Where(models.UserRole{UserID: userID, Role: role}).FirstOrCreate(&models.UserRole{}).Error
+}
+
+func (r *UserRepository) RemoveUserRole(userID uint, role string) error {
+	return r.db.Where("user_id = ? AND role = ?", userID, role).Delete(&models.UserRole{}).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/cache"
+	"github.com/Hickar/gin-rush/internal/dbtest"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestUpdateUserDuplicateKey(t *testing.T) {
+	db, dbMock := dbtest.NewMockDB(t)
+	client, _ := cache.NewCacheMock()
+	repo := NewUserRepository(db, client)
+
+	user := &models.User{Name: "user", Email: "taken@example.com"}
+	user.ID = 1
+
+	dbMock.ExpectExec("UPDATE `users`").WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+	if err := repo.UpdateUser(user); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected %v, got %v instead", ErrDuplicateKey, err)
+	}
+
+	if err := dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of DB expectations were not met: %s", err)
+	}
+}
+
+func TestUpdateConfirmationCode(t *testing.T) {
+	db, dbMock := dbtest.NewMockDB(t)
+	client, cacheMock := cache.NewCacheMock()
+	repo := NewUserRepository(db, client)
+
+	expiresAt := time.Now().Add(time.Hour)
+	user := &models.User{
+		Name:                  "stale name",
+		ConfirmationCode:      sql.NullString{String: "hash", Valid: true},
+		ConfirmationExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	}
+	user.ID = 1
+
+	dbMock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `updated_at`=?,`confirmation_code`=?,`confirmation_expires_at`=? WHERE `id` = ?")).
+		WithArgs(sqlmock.AnyArg(), "hash", expiresAt, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	cacheMock.ExpectDel("users:1").SetVal(1)
+
+	if err := repo.UpdateConfirmationCode(user); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of DB expectations were not met: %s", err)
+	}
+
+	if err := cacheMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of Redis expectations were not met: %s", err)
+	}
+}
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Hickar/gin-rush/pkg/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag"
+)
+
+// policyPasswordFields lists request properties validated by password policy
+var policyPasswordFields = map[string]string{
+	"request.CreateUserRequest":            "password",
+	"request.ChangePasswordRequest":        "new_password",
+	"request.CompletePasswordResetRequest": "password",
+}
+
+// currentPasswordFields lists request properties with existing password,
+// which are checked only against maximum length of password policy
+var currentPasswordFields = map[string]string{
+	"request.AuthUserRequest":             "password",
+	"request.PasswordConfirmationRequest": "password",
+	"request.ChangePasswordRequest":       "current_password",
+	"request.ChangeEmailRequest":          "password",
+	"request.DisableTOTPRequest":          "password",
+}
+
+// swaggerDoc serves generated swagger document with password constraints
+// replaced by the ones of configured password policy
+func swaggerDoc(policy validators.PasswordPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, err := swag.ReadDoc()
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		definitions, _ := doc["definitions"].(map[string]interface{})
+		for name, field := range policyPasswordFields {
+			if property, ok := definitionProperty(definitions, name, field); ok {
+				property["minLength"] = policy.MinLength
+				property["maxLength"] = policy.MaxLength
+				property["description"] = "Password " + policy.Description()
+			}
+		}
+
+		for name, field := range currentPasswordFields {
+			if property, ok := definitionProperty(definitions, name, field); ok {
+				property["maxLength"] = policy.MaxLength
+			}
+		}
+
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+func definitionProperty(definitions map[string]interface{}, name, field string) (map[string]interface{}, bool) {
+	definition, _ := definitions[name].(map[string]interface{})
+	properties, _ := definition["properties"].(map[string]interface{})
+	property, ok := properties[field].(map[string]interface{})
+	return property, ok
+}
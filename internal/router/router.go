@@ -6,46 +6,121 @@ import (
 	"github.com/Hickar/gin-rush/internal/api"
 	"github.com/Hickar/gin-rush/internal/config"
 	"github.com/Hickar/gin-rush/internal/middleware"
+	"github.com/Hickar/gin-rush/internal/models"
 	"github.com/Hickar/gin-rush/internal/validators"
+	"github.com/Hickar/gin-rush/pkg/logger"
+	"github.com/Hickar/gin-rush/pkg/ratelimit"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/go-redis/redis/v8"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewUserRouter(controller *api.UserController, conf *config.Config) *gin.Engine {
+func NewUserRouter(controller *api.UserController, conf *config.Config, cache *redis.Client, logger logger.Logger) (*gin.Engine, error) {
 	router := gin.New()
 
-	router.Use(gin.Logger())
+	limiter := ratelimit.NewFallbackLimiter(
+		ratelimit.NewRedisLimiter(cache, "rate_limits:"),
+		ratelimit.NewMemoryLimiter(),
+		logger.Error,
+	)
+
+	publicLimit, err := middleware.RateLimitGroup(limiter, &conf.RateLimit, "public")
+	if err != nil {
+		return nil, err
+	}
+
+	userLimit, err := middleware.RateLimitGroup(limiter, &conf.RateLimit, "user")
+	if err != nil {
+		return nil, err
+	}
+
+	router.Use(middleware.Logger())
 	router.Use(gin.Recovery())
 
+	policy := controller.UserUseCase.PasswordPolicy()
+
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("notblank", validators.NotBlank)
 		v.RegisterValidation("validemail", validators.ValidEmail)
-		v.RegisterValidation("validpassword", validators.ValidPassword)
+		v.RegisterValidation("validpassword", validators.PasswordValidator(policy))
+		v.RegisterValidation("passwordlength", validators.PasswordLengthValidator(policy))
 		v.RegisterValidation("validbirthdate", validators.ValidBirthDate)
 	}
 
+	router.SetHTMLTemplate(api.Templates())
+
 	router.GET("/", func(c *gin.Context) {
 		c.String(http.StatusOK, "")
 	})
 
-	user := router.Group(conf.Server.ApiUrl)
+	router.GET("/.well-known/jwks.json", controller.JWKS)
+
+	user := router.Group(conf.Server.ApiUrl, publicLimit)
 	{
 		user.POST("user", controller.CreateUser)
 		user.POST("/authorize", controller.AuthorizeUser)
-		user.GET("/authorize/email/challenge/:code", controller.EnableUser)
+		user.GET("/authorize/email/confirm", controller.ConfirmEmailPage)
+		user.POST("/authorize/email/confirm", controller.ConfirmEmail)
+		user.POST("/authorize/email/resend", controller.ResendConfirmation)
+		user.POST("/authorize/mfa", controller.VerifyMFA)
+		user.POST("/authorize/mfa/email", controller.VerifyEmailMFA)
+		user.POST("/authorize/magic-link", controller.RequestMagicLink)
+		user.POST("/authorize/magic-link/consume", controller.ConsumeMagicLink)
+		user.POST("/authorize/password/reset", controller.RequestPasswordReset)
+		user.POST("/authorize/password/reset/complete", controller.ResetPassword)
+		user.POST("/authorize/email/change", controller.ConfirmEmailChange)
+		user.POST("/token/refresh", controller.RefreshToken)
 	}
 
-	authUser := router.Group(conf.Server.ApiUrl, middleware.JWT())
+	if conf.Server.LegacyEmailChallenge {
+		router.GET(conf.Server.ApiUrl+"/authorize/email/challenge/:code", publicLimit, controller.EnableUser)
+	}
+
+	// Restricted tokens of users with unconfirmed email still allow to view
+	// profile, fix email address and log out
+	verified := middleware.RequireVerifiedEmail(conf.Server.EmailVerification)
+
+	authUser := router.Group(conf.Server.ApiUrl, middleware.JWT(controller.UserUseCase), userLimit)
 	{
 		authUser.GET("user/:id", controller.GetUser)
-		authUser.PATCH("user", controller.UpdateUser)
-		authUser.DELETE("user/:id", controller.DeleteUser)
+		authUser.PATCH("user", verified, controller.UpdateUser)
+		authUser.PUT("user/password", verified, controller.ChangePassword)
+		authUser.PUT("user/email", controller.ChangeEmail)
+		authUser.DELETE("user/:id", verified, controller.DeleteUser)
+		authUser.POST("user/mfa/totp", verified, controller.EnrollTOTP)
+		authUser.POST("user/mfa/totp/confirm", verified, controller.ConfirmTOTP)
+		authUser.DELETE("user/mfa/totp", verified, controller.DisableTOTP)
+		authUser.POST("user/mfa/email", verified, controller.EnableEmailMFA)
+		authUser.DELETE("user/mfa/email", verified, controller.DisableEmailMFA)
+		authUser.GET("user/sessions", controller.GetSessions)
+		authUser.DELETE("user/sessions/:id", controller.RevokeSession)
+		authUser.POST("/logout", controller.Logout)
+		authUser.POST("/logout/all", controller.LogoutEverywhere)
+	}
+
+	// Staff access to other users, handlers are shared with the routes above
+	users := router.Group(conf.Server.ApiUrl+"/users", middleware.JWT(controller.UserUseCase), userLimit, middleware.RequirePermission(models.PermissionReadUsers))
+	{
+		users.GET(":id", controller.GetUser)
+		users.DELETE(":id", middleware.RequirePermission(models.PermissionDeleteUsers), controller.DeleteUser)
+	}
+
+	admin := router.Group(conf.Server.ApiUrl+"/admin", middleware.JWT(controller.UserUseCase), userLimit, middleware.RequirePermission(models.PermissionManageUsers))
+	{
+		admin.GET("users", controller.ListUsers)
+		admin.GET("users/:id", controller.GetUserRecord)
+		admin.POST("users/:id/email/confirm", controller.ConfirmUserEmail)
+		admin.POST("users/:id/password/reset", controller.ForcePasswordReset)
+		admin.POST("users/:id/suspension", controller.SuspendUser)
+		admin.DELETE("users/:id/suspension", controller.UnsuspendUser)
+		admin.POST("users/:id/restore", controller.RestoreUser)
 	}
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/docs/swagger.json", swaggerDoc(policy))
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/docs/swagger.json")))
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+const defaultUserPageSize = 20
+
+// ListUsers returns page of users whose name or email contains query.
+// Pages are numbered from zero.
+func (uc *UserUseCase) ListUsers(query string, withDeleted bool, page, limit int) (*response.UserListResponse, error) {
+	if limit <= 0 {
+		limit = defaultUserPageSize
+	}
+
+	users, total, err := uc.repo.FindUsers(query, withDeleted, page*limit, limit)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't list users")
+	}
+
+	resp := &response.UserListResponse{Users: make([]response.AdminUserResponse, 0, len(users)), Total: total}
+	for i := range users {
+		resp.Users = append(resp.Users, *adminUserResponse(&users[i]))
+	}
+
+	return resp, nil
+}
+
+// GetUserRecord returns full record of user, which may be soft-deleted
+func (uc *UserUseCase) GetUserRecord(userID uint) (*response.AdminUserResponse, error) {
+	user, err := uc.repo.FindUserByIDWithDeleted(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	roles, err := uc.repo.FindUserRoles(user.ID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't load user roles")
+	}
+
+	resp := adminUserResponse(user)
+	resp.Roles = roles
+
+	return resp, nil
+}
+
+// ConfirmUserEmail marks email of user as confirmed without confirmation
+// code, which stops working
+func (uc *UserUseCase) ConfirmUserEmail(userID uint) error {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if user.Enabled {
+		return nil
+	}
+
+	user.Enabled = true
+	user.ConfirmationCode = sql.NullString{}
+	user.ConfirmationExpiresAt = sql.NullTime{}
+
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("unable to enable user")
+	}
+
+	return nil
+}
+
+// ForcePasswordReset replaces password of user with random one, terminates
+// every session and emails password reset link, so the user has to choose
+// new password before logging in again
+func (uc *UserUseCase) ForcePasswordReset(userID uint) error {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	password, err := security.RandomToken(32)
+	if err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't generate password")
+	}
+
+	if err := uc.replacePassword(user, password); err != nil {
+		return err
+	}
+
+	if err := uc.auth.RevokeUserOneTimeTokens(user.ID, magicLinkPurpose); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't revoke one-time tokens")
+	}
+
+	if err := uc.revokeAllTokens(user.ID); err != nil {
+		return err
+	}
+
+	return uc.sendPasswordReset(user)
+}
+
+// RestoreUser undoes deletion of user account
+func (uc *UserUseCase) RestoreUser(userID uint) error {
+	user, err := uc.repo.FindUserByIDWithDeleted(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if !user.DeletedAt.Valid {
+		return ErrUserNotDeleted
+	}
+
+	if err := uc.repo.RestoreUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't restore user")
+	}
+
+	return nil
+}
+
+func adminUserResponse(user *models.User) *response.AdminUserResponse {
+	resp := &response.AdminUserResponse{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		PendingEmail:    user.PendingEmail.String,
+		Bio:             user.Bio.String,
+		Avatar:          user.Avatar.String,
+		Enabled:         user.Enabled,
+		MFAEnabled:      user.MFAEnabled,
+		EmailMFAEnabled: user.EmailMFAEnabled,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+
+	if user.BirthDate.Valid {
+		resp.BirthDate = user.BirthDate.Time.Format("2006-01-02")
+	}
+
+	if user.DeletedAt.Valid {
+		resp.DeletedAt = &user.DeletedAt.Time
+	}
+
+	if suspensionActive(user) {
+		resp.SuspendedAt = &user.SuspendedAt.Time
+		resp.SuspensionReason = user.SuspensionReason.String
+
+		if user.SuspendedUntil.Valid {
+			resp.SuspendedUntil = &user.SuspendedUntil.Time
+		}
+
+		if user.SuspendedBy.Valid {
+			suspendedBy := uint(user.SuspendedBy.Int64)
+			resp.SuspendedBy = &suspendedBy
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestListUsers(t *testing.T) {
+	tc := newTestUseCase(t)
+
+	user := models.User{Name: "john", Email: "john@example.com"}
+	user.ID = 41
+
+	tc.db.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE \\(name LIKE (.+) OR email LIKE (.+)\\) AND `users`.`deleted_at` IS NULL").
+		WithArgs("%jo\\_%", "%jo\\_%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(41))
+	tc.db.ExpectQuery("SELECT \\* FROM `users` WHERE (.+) ORDER BY id LIMIT 20 OFFSET 40").
+		WithArgs("%jo\\_%", "%jo\\_%").
+		WillReturnRows(userRows(user))
+
+	resp, err := tc.ListUsers("jo_", false, 2, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if resp.Total != 41 || len(resp.Users) != 1 || resp.Users[0].ID != user.ID {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	tc.expectationsMet(t)
+}
+
+func TestGetUserRecord(t *testing.T) {
+	t.Run("DeletedUserShouldBeFound", func(t *testing.T) {
+		tc := newTestUseCase(t)
+
+		user := models.User{Name: "user", Email: "user@example.com"}
+		user.ID = 1
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+		tc.db.ExpectQuery("SELECT (.+) FROM `users` WHERE `users`.`id` = (.+)").WithArgs(1).WillReturnRows(userRows(user))
+		tc.db.ExpectQuery("SELECT `role` FROM `user_roles`").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(models.RoleModerator))
+
+		resp, err := tc.GetUserRecord(1)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if resp.DeletedAt == nil || len(resp.Roles) != 1 || resp.Roles[0] != models.RoleModerator {
+			t.Errorf("unexpected response %+v", resp)
+		}
+
+		tc.expectationsMet(t)
+	})
+
+	t.Run("MissingUserShouldNotBeFound", func(t *testing.T) {
+		tc := newTestUseCase(t)
+
+		tc.db.ExpectQuery("SELECT (.+) FROM `users`").WillReturnError(gorm.ErrRecordNotFound)
+
+		if _, err := tc.GetUserRecord(1); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("expected %v, got %v instead", ErrUserNotFound, err)
+		}
+
+		tc.expectationsMet(t)
+	})
+}
+
+func TestConfirmUserEmail(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Enabled bool
+	}{
+		{"PendingUserShouldBeEnabled", false},
+		{"EnabledUserShouldStayIntact", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			user := models.User{
+				Name:                  "user",
+				Email:                 "user@example.com",
+				Enabled:               tt.Enabled,
+				ConfirmationCode:      sql.NullString{String: "hash", Valid: !tt.Enabled},
+				ConfirmationExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: !tt.Enabled},
+			}
+			user.ID = 1
+
+			tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(1).WillReturnRows(userRows(user))
+			if !tt.Enabled {
+				tc.db.ExpectExec("UPDATE `users`").WillReturnResult(sqlmock.NewResult(0, 1))
+				tc.cache.ExpectDel("users:1").SetVal(1)
+			}
+
+			if err := tc.ConfirmUserEmail(1); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	tc := newTestUseCase(t)
+
+	hash, err := tc.hasher.Hash("Curr3nt!Secret#9")
+	if err != nil {
+		t.Fatalf("unable to hash password: %s", err)
+	}
+	user := models.User{Name: "user", Email: "user@example.com", Password: hash, Enabled: true}
+	user.ID = 1
+	tc.sessions.CreateSession(&models.Session{ID: "abc", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+
+	tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(1).WillReturnRows(userRows(user))
+	tc.db.ExpectExec("UPDATE `users`").WillReturnResult(sqlmock.NewResult(0, 1))
+	tc.cache.ExpectDel("users:1").SetVal(1)
+
+	// Previous password goes to history
+	tc.db.ExpectBegin()
+	tc.db.ExpectExec("INSERT INTO `password_histories`").WillReturnResult(sqlmock.NewResult(1, 1))
+	tc.db.ExpectQuery("SELECT `id` FROM `password_histories`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	tc.db.ExpectCommit()
+
+	tc.cache.ExpectSMembers("users:1:one_time_tokens:magic_link").SetVal([]string{"link"})
+	tc.cache.ExpectDel("users:1:one_time_tokens:magic_link", "one_time_tokens:magic_link:link").SetVal(2)
+
+	tc.cache.CustomMatch(matchKey).ExpectSet("users:1:tokens_revoked_at", 0, tc.jwt.MaxLifetime()).SetVal("OK")
+	tc.cache.ExpectSMembers("users:1:refresh_families").SetVal([]string{"abc"})
+	tc.cache.ExpectDel("users:1:refresh_families", "refresh_families:abc").SetVal(2)
+
+	// Reset link is issued for random token
+	tc.cache.ExpectTxPipeline()
+	tc.cache.CustomMatch(matchCommand).ExpectSet("", 1, passwordResetTTL).SetVal("OK")
+	tc.cache.CustomMatch(matchKey).ExpectSAdd("users:1:one_time_tokens:password_reset", "").SetVal(1)
+	tc.cache.ExpectExpire("users:1:one_time_tokens:password_reset", passwordResetTTL).SetVal(true)
+	tc.cache.ExpectTxPipelineExec()
+
+	if err := tc.ForcePasswordReset(1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if sessions, _ := tc.sessions.FindUserSessions(1); len(sessions) != 0 {
+		t.Errorf("sessions of user weren't terminated")
+	}
+
+	tc.expectationsMet(t)
+}
+
+func TestRestoreUser(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Deleted bool
+		Result  error
+	}{
+		{"DeletedUserShouldBeRestored", true, nil},
+		{"ActiveUserShouldFail", false, ErrUserNotDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			user := models.User{Name: "user", Email: "user@example.com"}
+			user.ID = 1
+			user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: tt.Deleted}
+
+			tc.db.ExpectQuery("SELECT (.+) FROM `users` WHERE `users`.`id` = (.+)").WithArgs(1).WillReturnRows(userRows(user))
+			if tt.Result == nil {
+				tc.db.ExpectExec("UPDATE `users` SET `deleted_at`=(.+),`updated_at`=(.+) WHERE `id` = (.+)").
+					WithArgs(nil, sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				tc.cache.ExpectDel("users:1").SetVal(1)
+			}
+
+			if err := tc.RestoreUser(1); !errors.Is(err, tt.Result) {
+				t.Errorf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+// issueTokens generates access JWT and refresh token pair for user within
+// session, which is also the refresh token family.
+func (uc *UserUseCase) issueTokens(user *models.User, family string) (*response.AuthUserResponse, error) {
+	roles, err := uc.repo.FindUserRoles(user.ID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't load user roles")
+	}
+
+	token, err := uc.jwt.GenerateJWT(&security.Claims{
+		UserID:        user.ID,
+		SessionID:     family,
+		EmailVerified: user.Enabled,
+		Roles:         roles,
+		Permissions:   uc.rolePermissions(roles),
+	})
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't generate jwt")
+	}
+
+	refreshToken, err := security.RandomToken(32)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't generate refresh token")
+	}
+
+	ttl := time.Duration(uc.conf.Server.RefreshTokenTTL) * time.Second
+	record := &models.RefreshToken{UserID: user.ID, Family: family}
+
+	if err := uc.auth.CreateRefreshToken(security.HashToken(refreshToken), record, ttl); err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't store refresh token")
+	}
+
+	return &response.AuthUserResponse{Token: token, RefreshToken: refreshToken}, nil
+}
+
+// RefreshToken exchanges refresh token for a new token pair. Every refresh
+// token can be used only once: presenting already used token revokes the
+// whole family it belongs to, logging out both legitimate client and attacker.
+func (uc *UserUseCase) RefreshToken(refreshToken string, client ClientInfo) (*response.AuthUserResponse, error) {
+	record, err := uc.auth.UseRefreshToken(security.HashToken(refreshToken))
+	if err != nil {
+		if !errors.Is(err, repository.ErrTokenNotFound) {
+			uc.logger.Error(err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if record.Used {
+		uc.logger.Warning(fmt.Sprintf("refresh token reuse detected for user %d, revoking family", record.UserID))
+
+		if err := uc.auth.RevokeRefreshFamily(record.Family); err != nil {
+			uc.logger.Error(err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	active, err := uc.auth.RefreshFamilyExists(record.Family)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if !active {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := uc.repo.FindUserByID(record.UserID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err := uc.checkSuspended(user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.refreshSession(record.Family, user.ID, client); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		uc.logger.Error(err)
+		return nil, errors.New("can't refresh session")
+	}
+
+	return uc.issueTokens(user, record.Family)
+}
+
+// Authenticate parses access token and makes sure neither the token nor its
+// session were revoked. Suspension of user is checked first, since it
+// revokes the tokens as well.
+func (uc *UserUseCase) Authenticate(token string) (*security.Claims, error) {
+	claims, err := uc.jwt.ParseJWT(token)
+	if err != nil || claims.Purpose != "" {
+		return nil, ErrInvalidToken
+	}
+
+	if err := uc.checkTokenSuspended(claims.UserID); err != nil {
+		return nil, err
+	}
+
+	revoked, err := uc.auth.AccessTokenRevoked(claims.Id, claims.UserID, claims.IssuedAtMillis())
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, ErrInvalidToken
+	}
+
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	if err := uc.checkSession(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// JWKS returns public keys which can be used to verify issued tokens
+func (uc *UserUseCase) JWKS() security.JWKS {
+	return uc.jwt.JWKS()
+}
+
+// Logout revokes access token described by claims and ends its session
+// together with refresh tokens issued after the same login
+func (uc *UserUseCase) Logout(claims *security.Claims) error {
+	ttl := time.Until(uc.jwt.AcceptedUntil(claims))
+
+	if err := uc.auth.RevokeAccessToken(claims.Id, ttl); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't revoke access token")
+	}
+
+	if claims.SessionID != "" {
+		return uc.deleteSession(claims.UserID, claims.SessionID)
+	}
+
+	return nil
+}
+
+// LogoutEverywhere revokes every access and refresh token issued to user
+func (uc *UserUseCase) LogoutEverywhere(userID uint) error {
+	return uc.revokeAllTokens(userID)
+}
+
+func (uc *UserUseCase) revokeAllTokens(userID uint) error {
+	if err := uc.auth.RevokeUserAccessTokens(userID, uc.jwt.MaxLifetime()); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't revoke access tokens")
+	}
+
+	if err := uc.auth.RevokeUserRefreshFamilies(userID); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't revoke refresh tokens")
+	}
+
+	if err := uc.sessions.DeleteUserSessions(userID); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't delete sessions")
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/pkg/security"
+	"github.com/Hickar/gin-rush/pkg/utils"
+)
+
+const (
+	confirmationPurpose      = "confirmation"
+	confirmationCodeLength   = 30
+	confirmationCodeTTL      = time.Hour * 24
+	confirmationResendPeriod = time.Minute
+)
+
+// newTokenSigner creates signer of stateless tokens from configured key.
+// Signer is nil if no key is set, unless signed confirmation tokens require it.
+func newTokenSigner(conf *config.Config) (*security.TokenSigner, error) {
+	switch conf.Server.ConfirmationTokens {
+	case config.ConfirmationTokenCode, config.ConfirmationTokenSigned:
+	default:
+		return nil, errors.New("unknown confirmation token kind")
+	}
+
+	if conf.Server.TokenSigningKey == "" {
+		if conf.Server.ConfirmationTokens == config.ConfirmationTokenSigned {
+			return nil, errors.New("token signing key is required for signed confirmation tokens")
+		}
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(conf.Server.TokenSigningKey)
+	if err != nil {
+		return nil, errors.New("token signing key must be base64-encoded")
+	}
+
+	return security.NewTokenSigner(key)
+}
+
+// checkEmailVerified rejects login of user with unconfirmed email if
+// email verification policy blocks it
+func (uc *UserUseCase) checkEmailVerified(user *models.User) error {
+	if !user.Enabled && uc.conf.Server.EmailVerification == config.EmailVerificationBlock {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+// setConfirmationCode generates new email confirmation code for user,
+// replacing the previous one. Only hash of the code is stored.
+func setConfirmationCode(user *models.User) string {
+	code := utils.RandomString(confirmationCodeLength)
+
+	user.ConfirmationCode = sql.NullString{String: security.HashToken(code), Valid: true}
+	user.ConfirmationExpiresAt = sql.NullTime{Time: time.Now().Add(confirmationCodeTTL), Valid: true}
+
+	return code
+}
+
+// newConfirmationCode returns code to be emailed to user, which is either
+// signed token or random code saved with the user, depending on config.
+// Signed token requires user to be already created.
+func (uc *UserUseCase) newConfirmationCode(user *models.User) (string, error) {
+	if uc.conf.Server.ConfirmationTokens == config.ConfirmationTokenSigned {
+		return uc.signer.Sign(confirmationPurpose, user.ID, user.Email, confirmationCodeTTL), nil
+	}
+
+	code := setConfirmationCode(user)
+	return code, uc.repo.UpdateConfirmationCode(user)
+}
+
+// ConfirmEmail enables user the confirmation code was sent to. Both random
+// codes and signed tokens are accepted, so that switching between them
+// doesn't break links already sent. Either of them can be used only once:
+// random code is cleared and signed token stops working once user is enabled.
+func (uc *UserUseCase) ConfirmEmail(code string) (*models.User, error) {
+	var user *models.User
+	var err error
+
+	switch {
+	case len(code) == confirmationCodeLength:
+		user, err = uc.findUserByConfirmationCode(code)
+	case uc.signer != nil:
+		user, err = uc.verifyConfirmationToken(code)
+	default:
+		return nil, ErrUnprocessableEntity
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Enabled || user.ConfirmationCode.Valid {
+		user.Enabled = true
+		user.ConfirmationCode = sql.NullString{}
+		user.ConfirmationExpiresAt = sql.NullTime{}
+
+		if err := uc.repo.UpdateUser(user); err != nil {
+			uc.logger.Error(err)
+			return nil, errors.New("unable to enable user")
+		}
+	}
+
+	return user, nil
+}
+
+func (uc *UserUseCase) findUserByConfirmationCode(code string) (*models.User, error) {
+	user, err := uc.repo.FindUserByConfirmationCode(security.HashToken(code))
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.ConfirmationExpiresAt.Valid && time.Now().After(user.ConfirmationExpiresAt.Time) {
+		return nil, ErrConfirmationExpired
+	}
+
+	return user, nil
+}
+
+// verifyConfirmationToken checks signed token against current email of the
+// user, so the token is invalidated by email change. Token of already enabled
+// user is rejected as well, otherwise it would work as password-less login
+// until it expires.
+func (uc *UserUseCase) verifyConfirmationToken(token string) (*models.User, error) {
+	var user *models.User
+
+	_, err := uc.signer.Verify(token, confirmationPurpose, func(userID uint) (string, error) {
+		var err error
+		user, err = uc.repo.FindUserByID(userID)
+		if err != nil {
+			return "", err
+		}
+
+		if user.Enabled {
+			return "", ErrUserNotFound
+		}
+		return user.Email, nil
+	})
+
+	switch {
+	case err == nil:
+		return user, nil
+	case errors.Is(err, security.ErrSignedTokenExpired):
+		return nil, ErrConfirmationExpired
+	default:
+		return nil, ErrUserNotFound
+	}
+}
+
+// ResendConfirmation emails new confirmation code to user with given email
+// if the account isn't enabled yet. Requests for the same email are
+// throttled whether or not it's registered, and the code is sent in
+// background, so the response doesn't tell which addresses are registered.
+func (uc *UserUseCase) ResendConfirmation(email string) error {
+	retryAfter, err := uc.auth.AcquireCooldown("confirmation:"+strings.ToLower(email), confirmationResendPeriod)
+	if err != nil {
+		uc.logger.Error(err)
+	}
+
+	if retryAfter > 0 {
+		return &RetryAfterError{Err: ErrConfirmationThrottled, RetryAfter: retryAfter}
+	}
+
+	user, err := uc.repo.FindUserByEmail(email)
+	if err != nil || user.Enabled {
+		return nil
+	}
+
+	go func() {
+		if err := uc.sendConfirmationCode(user); err != nil {
+			uc.logger.Error(err)
+		}
+	}()
+
+	return nil
+}
+
+func (uc *UserUseCase) sendConfirmationCode(user *models.User) error {
+	code, err := uc.newConfirmationCode(user)
+	if err != nil {
+		return err
+	}
+
+	return uc.sendMail(mailer.ConfirmationKey, &mailer.ConfirmationMessage{
+		Username: user.Name,
+		Email:    user.Email,
+		Code:     code,
+		Hours:    int(confirmationCodeTTL / time.Hour),
+	})
+}
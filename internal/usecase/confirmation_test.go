@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/pkg/security"
+	"gorm.io/gorm"
+)
+
+func TestConfirmEmail(t *testing.T) {
+	const code = "0123456789abcdefghijklmnopqrst"
+
+	tests := []struct {
+		Name      string
+		ExpiresAt time.Time
+		Found     bool
+		Result    error
+	}{
+		{"ValidCodeShouldEnableUser", time.Now().Add(time.Hour), true, nil},
+		{"ExpiredCodeShouldFail", time.Now().Add(-time.Minute), true, ErrConfirmationExpired},
+		{"UnknownCodeShouldFail", time.Time{}, false, ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			user := models.User{
+				Name:                  "user",
+				Email:                 "user@example.com",
+				ConfirmationCode:      sql.NullString{String: security.HashToken(code), Valid: true},
+				ConfirmationExpiresAt: sql.NullTime{Time: tt.ExpiresAt, Valid: true},
+			}
+			user.ID = 1
+
+			query := tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(security.HashToken(code))
+			if !tt.Found {
+				query.WillReturnError(gorm.ErrRecordNotFound)
+			} else {
+				query.WillReturnRows(userRows(user))
+			}
+
+			if tt.Result == nil {
+				tc.db.ExpectExec("UPDATE `users`").WillReturnResult(sqlmock.NewResult(0, 1))
+				tc.cache.ExpectDel("users:1").SetVal(1)
+			}
+
+			confirmed, err := tc.ConfirmEmail(code)
+			if !errors.Is(err, tt.Result) {
+				t.Fatalf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			if err == nil && (!confirmed.Enabled || confirmed.ConfirmationCode.Valid || confirmed.ConfirmationExpiresAt.Valid) {
+				t.Errorf("confirmation code of enabled user wasn't cleared")
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
+
+func TestResendConfirmationThrottled(t *testing.T) {
+	tc := newTestUseCase(t)
+
+	tc.cache.ExpectSetNX("cooldowns:confirmation:user@example.com", 1, confirmationResendPeriod).SetVal(false)
+	tc.cache.ExpectPTTL("cooldowns:confirmation:user@example.com").SetVal(40 * time.Second)
+
+	err := tc.ResendConfirmation("User@Example.com")
+	if !errors.Is(err, ErrConfirmationThrottled) {
+		t.Fatalf("expected %v, got %v instead", ErrConfirmationThrottled, err)
+	}
+
+	var retryErr *RetryAfterError
+	if !errors.As(err, &retryErr) || retryErr.RetryAfter != 40*time.Second {
+		t.Errorf("expected retry after 40s, got %v instead", err)
+	}
+
+	tc.expectationsMet(t)
+}
+
+func TestConfirmEmailSignedToken(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Enabled bool
+		Result  error
+	}{
+		{"PendingUserShouldBeEnabled", false, nil},
+		{"EnabledUserShouldRejectReplay", true, ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			signer, err := security.NewTokenSigner([]byte("0123456789abcdef0123456789abcdef"))
+			if err != nil {
+				t.Fatalf("unable to create token signer: %s", err)
+			}
+			tc.signer = signer
+
+			user := models.User{Name: "user", Email: "user@example.com", Enabled: tt.Enabled}
+			user.ID = 1
+			token := signer.Sign(confirmationPurpose, user.ID, user.Email, confirmationCodeTTL)
+
+			tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(user.ID).WillReturnRows(userRows(user))
+			if tt.Result == nil {
+				tc.db.ExpectExec("UPDATE `users`").WillReturnResult(sqlmock.NewResult(0, 1))
+				tc.cache.ExpectDel("users:1").SetVal(1)
+			}
+
+			if _, err := tc.ConfirmEmail(token); !errors.Is(err, tt.Result) {
+				t.Errorf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
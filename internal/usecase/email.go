@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+const (
+	emailChangePurpose = "email_change"
+	emailChangeTTL     = time.Hour * 24
+)
+
+// RequestEmailChange stores new address of user as pending and sends
+// confirmation link to it. Previous address gets notice about the change.
+// Only the latest requested address can be confirmed.
+func (uc *UserUseCase) RequestEmailChange(userID uint, email, password string) error {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrUserNotFound
+	}
+
+	if !uc.verifyPassword(user, password) {
+		return ErrInvalidPassword
+	}
+
+	if exists, _ := uc.repo.UserWithEmailExists(email); exists {
+		return ErrUserExists
+	}
+
+	token, err := security.RandomToken(32)
+	if err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't generate email change token")
+	}
+
+	if err := uc.auth.RevokeUserOneTimeTokens(user.ID, emailChangePurpose); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't revoke previous email change tokens")
+	}
+
+	if err := uc.auth.CreateOneTimeToken(emailChangePurpose, security.HashToken(token), user.ID, emailChangeTTL); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't store email change token")
+	}
+
+	user.PendingEmail.String, user.PendingEmail.Valid = email, true
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't save pending email")
+	}
+
+	err = uc.sendMail(mailer.EmailChangeKey, &mailer.EmailChangeMessage{
+		Username: user.Name,
+		Email:    email,
+		Token:    token,
+	})
+	if err != nil {
+		return err
+	}
+
+	return uc.sendMail(mailer.EmailChangedKey, &mailer.EmailChangedMessage{
+		Username: user.Name,
+		Email:    user.Email,
+		NewEmail: email,
+	})
+}
+
+// ConfirmEmailChange replaces email of user with pending one. Address is
+// checked once again, since it might be taken after change was requested.
+func (uc *UserUseCase) ConfirmEmailChange(token string) error {
+	userID, err := uc.auth.UseOneTimeToken(emailChangePurpose, security.HashToken(token))
+	if err != nil {
+		if !errors.Is(err, repository.ErrTokenNotFound) {
+			uc.logger.Error(err)
+		}
+		return ErrInvalidEmailToken
+	}
+
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrInvalidEmailToken
+	}
+
+	if !user.PendingEmail.Valid {
+		return ErrInvalidEmailToken
+	}
+
+	if exists, _ := uc.repo.UserWithEmailExists(user.PendingEmail.String); exists {
+		return ErrUserExists
+	}
+
+	user.Email = user.PendingEmail.String
+	user.PendingEmail.String, user.PendingEmail.Valid = "", false
+	user.Enabled = true
+
+	// Address may be taken between the check and the update as well
+	if err := uc.repo.UpdateUser(user); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return ErrUserExists
+		}
+		uc.logger.Error(err)
+		return errors.New("can't change email")
+	}
+
+	return nil
+}
@@ -1,6 +1,11 @@
 package usecase
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/Hickar/gin-rush/pkg/response"
+)
 
 var (
 	ErrUserExists = errors.New("user with such email already exists")
@@ -8,4 +13,72 @@ var (
 	ErrInvalidPassword = errors.New("invalid user password")
 	ErrUserForbidden       = errors.New("authenticated user doesn't allowed to update user")
 	ErrUnprocessableEntity = errors.New("invalid data format")
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrInvalidToken        = errors.New("access token is invalid or expired")
+	ErrTokenRevoked        = errors.New("access token was revoked")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFAToken     = errors.New("two-factor authentication token is invalid or expired")
+	ErrInvalidMFACode      = errors.New("invalid two-factor authentication code")
+	ErrEmailNotVerified    = errors.New("email address is not verified")
+	ErrInvalidMagicLink    = errors.New("magic link is invalid or expired")
+	ErrInvalidResetToken   = errors.New("password reset token is invalid or expired")
+	ErrInvalidEmailToken   = errors.New("email change token is invalid or expired")
+	ErrPasswordBreached    = errors.New("password was found in data breach, please choose another one")
+	ErrWeakPassword        = errors.New("password doesn't meet password policy")
+	ErrPasswordReused      = errors.New("password was used recently, please choose another one")
+	ErrPasswordHistory     = errors.New("password history can't be checked, please try again later")
+	ErrConfirmationExpired = errors.New("confirmation code has expired")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrUserSuspended       = errors.New("user account is suspended")
+	ErrUserNotDeleted      = errors.New("user account is not deleted")
 )
+
+var (
+	ErrTooManyAttempts       = errors.New("too many failed login attempts")
+	ErrAccountLocked         = errors.New("account is temporarily locked")
+	ErrConfirmationThrottled = errors.New("confirmation email was sent recently")
+)
+
+// RetryAfterError wraps error caused by throttling with duration after
+// which the request may be repeated
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// SuspendedError is returned instead of ErrUserSuspended when suspension
+// details are known. Until is zero if suspension is indefinite. It satisfies
+// middleware.Suspension, so that rejected token is described to the user.
+type SuspendedError struct {
+	Reason string
+	Until  time.Time
+}
+
+func (e *SuspendedError) Error() string {
+	return ErrUserSuspended.Error()
+}
+
+func (e *SuspendedError) Unwrap() error {
+	return ErrUserSuspended
+}
+
+// Response describes suspension to the suspended user
+func (e *SuspendedError) Response() *response.SuspensionResponse {
+	resp := &response.SuspensionResponse{Error: e.Error(), Reason: e.Reason}
+	if !e.Until.IsZero() {
+		until := e.Until
+		resp.Until = &until
+	}
+
+	return resp
+}
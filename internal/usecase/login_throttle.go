@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+)
+
+// Login throttling policy. Every failure after free attempts doubles delay
+// before the next attempt is allowed, and reaching lockout threshold blocks
+// login for lockout duration. Limits for client IP are higher, since many
+// users may share the same address.
+const (
+	loginFailuresWindow = time.Hour
+	loginBaseDelay      = time.Second
+	loginMaxDelay       = time.Minute * 5
+
+	accountFreeAttempts    = 3
+	accountLockoutAttempts = 10
+	accountLockoutDuration = time.Minute * 15
+
+	ipFreeAttempts    = 20
+	ipLockoutAttempts = 100
+	ipLockoutDuration = time.Hour
+)
+
+type loginSubject struct {
+	key             string
+	freeAttempts    int64
+	lockoutAttempts int64
+	lockoutDuration time.Duration
+	lockoutErr      error
+}
+
+func loginSubjects(email, ip string) []loginSubject {
+	return []loginSubject{
+		{
+			key:             "account:" + strings.ToLower(email),
+			freeAttempts:    accountFreeAttempts,
+			lockoutAttempts: accountLockoutAttempts,
+			lockoutDuration: accountLockoutDuration,
+			lockoutErr:      ErrAccountLocked,
+		},
+		{
+			key:             "ip:" + ip,
+			freeAttempts:    ipFreeAttempts,
+			lockoutAttempts: ipLockoutAttempts,
+			lockoutDuration: ipLockoutDuration,
+			lockoutErr:      ErrTooManyAttempts,
+		},
+	}
+}
+
+// checkLoginAllowed returns RetryAfterError if any of subjects is
+// currently delayed or locked out
+func (uc *UserUseCase) checkLoginAllowed(subjects []loginSubject) error {
+	for _, subject := range subjects {
+		for _, kind := range []string{repository.LoginLockout, repository.LoginDelay} {
+			ttl, err := uc.auth.LoginBlockedFor(kind, subject.key)
+			if err != nil {
+				uc.logger.Error(err)
+				return errors.New("can't check login attempts")
+			}
+
+			if ttl <= 0 {
+				continue
+			}
+
+			reason := ErrTooManyAttempts
+			if kind == repository.LoginLockout {
+				reason = subject.lockoutErr
+			}
+			return &RetryAfterError{Err: reason, RetryAfter: ttl}
+		}
+	}
+
+	return nil
+}
+
+// recordLoginFailure counts failed attempt for every subject and blocks
+// further attempts according to policy. Owner of account is notified by
+// email once it gets locked.
+func (uc *UserUseCase) recordLoginFailure(subjects []loginSubject, user *models.User) {
+	for i, subject := range subjects {
+		count, err := uc.auth.RecordLoginFailure(subject.key, loginFailuresWindow)
+		if err != nil {
+			uc.logger.Error(err)
+			continue
+		}
+
+		if count >= subject.lockoutAttempts {
+			if err := uc.auth.BlockLogin(repository.LoginLockout, subject.key, subject.lockoutDuration); err != nil {
+				uc.logger.Error(err)
+			}
+
+			if i == 0 && count == subject.lockoutAttempts {
+				uc.logger.Warning(fmt.Sprintf("login of %s locked after %d failed attempts", subject.key, count))
+				uc.sendLockoutNotice(user, subject.lockoutDuration)
+			}
+			continue
+		}
+
+		if count > subject.freeAttempts {
+			if err := uc.auth.BlockLogin(repository.LoginDelay, subject.key, loginDelay(count-subject.freeAttempts)); err != nil {
+				uc.logger.Error(err)
+			}
+		}
+	}
+}
+
+func (uc *UserUseCase) sendLockoutNotice(user *models.User, duration time.Duration) {
+	if user == nil {
+		return
+	}
+
+	err := uc.sendMail(mailer.AccountLockedKey, &mailer.AccountLockedMessage{
+		Username: user.Name,
+		Email:    user.Email,
+		Minutes:  int(duration / time.Minute),
+	})
+	if err != nil {
+		uc.logger.Error(err)
+	}
+}
+
+// loginDelay returns delay after n-th failure beyond free attempts
+func loginDelay(n int64) time.Duration {
+	delay := float64(loginBaseDelay) * math.Pow(2, float64(n-1))
+	if delay > float64(loginMaxDelay) {
+		return loginMaxDelay
+	}
+
+	return time.Duration(delay)
+}
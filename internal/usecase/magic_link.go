@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+const (
+	magicLinkPurpose = "magic_link"
+	magicLinkTTL     = time.Minute * 15
+)
+
+// RequestMagicLink emails single-use sign-in link to user with given email.
+// Unknown email is silently ignored and link is sent in background, so
+// neither response nor its timing tell which addresses are registered.
+func (uc *UserUseCase) RequestMagicLink(email string) {
+	user, err := uc.repo.FindUserByEmail(email)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		if err := uc.sendMagicLink(user); err != nil {
+			uc.logger.Error(err)
+		}
+	}()
+}
+
+func (uc *UserUseCase) sendMagicLink(user *models.User) error {
+	token, err := security.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.auth.CreateOneTimeToken(magicLinkPurpose, security.HashToken(token), user.ID, magicLinkTTL); err != nil {
+		return err
+	}
+
+	return uc.sendMail(mailer.MagicLinkKey, &mailer.MagicLinkMessage{
+		Username: user.Name,
+		Email:    user.Email,
+		Token:    token,
+	})
+}
+
+// ConsumeMagicLink signs user in with token from magic link. Second factor
+// is still required if user has one enabled.
+func (uc *UserUseCase) ConsumeMagicLink(token string, client ClientInfo) (*response.AuthUserResponse, error) {
+	userID, err := uc.auth.UseOneTimeToken(magicLinkPurpose, security.HashToken(token))
+	if err != nil {
+		if !errors.Is(err, repository.ErrTokenNotFound) {
+			uc.logger.Error(err)
+		}
+		return nil, ErrInvalidMagicLink
+	}
+
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, ErrInvalidMagicLink
+	}
+
+	return uc.completeLogin(user, client)
+}
@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Hickar/gin-rush/internal/mailer"
+)
+
+// sendMail publishes message for mailer worker with given routing key
+func (uc *UserUseCase) sendMail(key string, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't encode mailer message")
+	}
+
+	if err := uc.broker.Publish(mailer.Exchange, key, "text/plain", &body); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't publish message to broker")
+	}
+
+	return nil
+}
@@ -0,0 +1,470 @@
+package usecase
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+const (
+	mfaMethodTOTP  = "totp"
+	mfaMethodEmail = "email"
+
+	mfaTokenTTL         = time.Minute * 10
+	recoveryCodesCount  = 10
+	emailOTPTTL         = time.Minute * 10
+	emailOTPMaxAttempts = 5
+	mfaTokenMaxFailures = 5
+)
+
+// completeLogin issues token pair for user who passed the first
+// authentication factor, or short-lived MFA token if second one is required.
+// With email factor enabled one-time code is sent right away.
+func (uc *UserUseCase) completeLogin(user *models.User, client ClientInfo) (*response.AuthUserResponse, error) {
+	if err := uc.checkSuspended(user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	if user.MFAEnabled {
+		methods = append(methods, mfaMethodTOTP)
+	}
+	if user.EmailMFAEnabled {
+		methods = append(methods, mfaMethodEmail)
+	}
+
+	if len(methods) == 0 {
+		return uc.startSession(user, client)
+	}
+
+	claims := &security.Claims{UserID: user.ID, Purpose: security.PurposeMFA}
+	claims.ExpiresAt = time.Now().Add(mfaTokenTTL).Unix()
+
+	token, err := uc.jwt.GenerateJWT(claims)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't generate mfa token")
+	}
+
+	if user.EmailMFAEnabled {
+		if err := uc.sendEmailOTP(user, claims.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	return &response.AuthUserResponse{MFARequired: true, MFAToken: token, MFAMethods: methods}, nil
+}
+
+// VerifyMFA exchanges MFA token issued after password check plus TOTP or
+// recovery code for token pair. MFA token can be exchanged only once.
+func (uc *UserUseCase) VerifyMFA(mfaToken, code, recoveryCode string, client ClientInfo) (*response.AuthUserResponse, error) {
+	claims, user, err := uc.parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MFAEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+
+	err = uc.throttleMFA(claims, user, client, func() error {
+		return uc.verifySecondFactor(user, code, recoveryCode)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.finishMFA(user, claims, client)
+}
+
+// VerifyEmailMFA exchanges MFA token and one-time code sent by email for
+// token pair. Each code can be checked limited number of times.
+func (uc *UserUseCase) VerifyEmailMFA(mfaToken, code string, client ClientInfo) (*response.AuthUserResponse, error) {
+	claims, user, err := uc.parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.EmailMFAEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+
+	err = uc.throttleMFA(claims, user, client, func() error {
+		valid, err := uc.auth.VerifyEmailOTP(claims.Id, security.KeyedHash(uc.mfaKey, code), emailOTPMaxAttempts)
+		if err != nil {
+			if errors.Is(err, repository.ErrTokenNotFound) {
+				return ErrInvalidMFAToken
+			}
+			uc.logger.Error(err)
+			return errors.New("can't verify email code")
+		}
+
+		if !valid {
+			return ErrInvalidMFACode
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.finishMFA(user, claims, client)
+}
+
+// EnableEmailMFA turns on sign-in codes sent by email. Only verified
+// address may be used as the second factor.
+func (uc *UserUseCase) EnableEmailMFA(userID uint) error {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrUserNotFound
+	}
+
+	if user.EmailMFAEnabled {
+		return ErrMFAAlreadyEnabled
+	}
+
+	if !user.Enabled {
+		return ErrEmailNotVerified
+	}
+
+	user.EmailMFAEnabled = true
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't enable email two-factor authentication")
+	}
+
+	return nil
+}
+
+// DisableEmailMFA turns off sign-in codes sent by email, requiring
+// current password
+func (uc *UserUseCase) DisableEmailMFA(userID uint, password string) error {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrUserNotFound
+	}
+
+	if !user.EmailMFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if !uc.verifyPassword(user, password) {
+		return ErrInvalidPassword
+	}
+
+	user.EmailMFAEnabled = false
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't disable email two-factor authentication")
+	}
+
+	return nil
+}
+
+func (uc *UserUseCase) parseMFAToken(mfaToken string) (*security.Claims, *models.User, error) {
+	claims, err := uc.jwt.ParseJWT(mfaToken)
+	if err != nil || claims.Purpose != security.PurposeMFA {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	revoked, err := uc.auth.AccessTokenRevoked(claims.Id, claims.UserID, claims.IssuedAtMillis())
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	if revoked {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	user, err := uc.repo.FindUserByID(claims.UserID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	return claims, user, nil
+}
+
+// throttleMFA runs check of the second factor under login throttling of
+// account and client, counting wrong codes and passwords as failed login
+// attempts, so the code can't be guessed by requesting new tokens. Token
+// presented with the code is revoked after mfaTokenMaxFailures wrong codes.
+func (uc *UserUseCase) throttleMFA(claims *security.Claims, user *models.User, client ClientInfo, verify func() error) error {
+	subjects := loginSubjects(user.Email, client.IP)
+	if err := uc.checkLoginAllowed(subjects); err != nil {
+		return err
+	}
+
+	err := verify()
+	if err == nil {
+		if err := uc.auth.ResetLoginFailures(subjects[0].key); err != nil {
+			uc.logger.Error(err)
+		}
+		return nil
+	}
+
+	if !errors.Is(err, ErrInvalidMFACode) && !errors.Is(err, ErrInvalidPassword) {
+		return err
+	}
+
+	uc.recordLoginFailure(subjects, user)
+
+	failures, recordErr := uc.auth.RecordLoginFailure("mfa_token:"+claims.Id, mfaTokenTTL)
+	if recordErr != nil {
+		uc.logger.Error(recordErr)
+		return err
+	}
+
+	if failures >= mfaTokenMaxFailures {
+		if err := uc.auth.RevokeAccessToken(claims.Id, time.Until(uc.jwt.AcceptedUntil(claims))); err != nil {
+			uc.logger.Error(err)
+		}
+	}
+
+	return err
+}
+
+// finishMFA revokes MFA token which was just exchanged and starts session
+func (uc *UserUseCase) finishMFA(user *models.User, claims *security.Claims, client ClientInfo) (*response.AuthUserResponse, error) {
+	if err := uc.checkSuspended(user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.auth.RevokeAccessToken(claims.Id, time.Until(uc.jwt.AcceptedUntil(claims))); err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't revoke mfa token")
+	}
+
+	return uc.startSession(user, client)
+}
+
+// sendEmailOTP emails one-time code bound to MFA token with given ID
+func (uc *UserUseCase) sendEmailOTP(user *models.User, challenge string) error {
+	code, err := security.RandomDigits(6)
+	if err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't generate email code")
+	}
+
+	if err := uc.auth.CreateEmailOTP(challenge, security.KeyedHash(uc.mfaKey, code), emailOTPTTL); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't store email code")
+	}
+
+	return uc.sendMail(mailer.OneTimeCodeKey, &mailer.OneTimeCodeMessage{
+		Username: user.Name,
+		Email:    user.Email,
+		Code:     code,
+	})
+}
+
+// EnrollTOTP generates new TOTP secret for user. Secret doesn't take effect
+// until it's confirmed with ConfirmTOTP.
+func (uc *UserUseCase) EnrollTOTP(userID uint) (*response.TOTPEnrollmentResponse, error) {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, ErrUserNotFound
+	}
+
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't generate totp secret")
+	}
+
+	encrypted, err := security.Encrypt(secret, uc.mfaKey, mfaAdditionalData(user.ID))
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't encrypt totp secret")
+	}
+
+	user.MFASecret = encrypted
+	user.MFALastStep = 0
+
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't save totp secret")
+	}
+
+	return &response.TOTPEnrollmentResponse{
+		Secret: security.EncodeTOTPSecret(secret),
+		URI:    security.TOTPURI(uc.conf.Server.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once user proves that
+// authenticator app was set up, returning freshly generated recovery codes
+func (uc *UserUseCase) ConfirmTOTP(claims *security.Claims, code string, client ClientInfo) (*response.RecoveryCodesResponse, error) {
+	user, err := uc.repo.FindUserByID(claims.UserID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, ErrUserNotFound
+	}
+
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if len(user.MFASecret) == 0 {
+		return nil, ErrMFANotEnabled
+	}
+
+	err = uc.throttleMFA(claims, user, client, func() error {
+		return uc.verifyTOTP(user, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't generate recovery codes")
+	}
+
+	if err := uc.repo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't save recovery codes")
+	}
+
+	user.MFAEnabled = true
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't enable two-factor authentication")
+	}
+
+	return &response.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off, requiring current
+// password and valid TOTP or recovery code
+func (uc *UserUseCase) DisableTOTP(claims *security.Claims, password, code, recoveryCode string, client ClientInfo) error {
+	user, err := uc.repo.FindUserByID(claims.UserID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrUserNotFound
+	}
+
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	err = uc.throttleMFA(claims, user, client, func() error {
+		if !uc.verifyPassword(user, password) {
+			return ErrInvalidPassword
+		}
+		return uc.verifySecondFactor(user, code, recoveryCode)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.ReplaceRecoveryCodes(user.ID, nil); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't delete recovery codes")
+	}
+
+	user.MFAEnabled = false
+	user.MFASecret = nil
+	user.MFALastStep = 0
+
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't disable two-factor authentication")
+	}
+
+	return nil
+}
+
+func (uc *UserUseCase) verifySecondFactor(user *models.User, code, recoveryCode string) error {
+	if code != "" {
+		return uc.verifyTOTP(user, code)
+	}
+
+	used, err := uc.repo.UseRecoveryCode(user.ID, security.HashToken(normalizeRecoveryCode(recoveryCode)))
+	if err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't verify recovery code")
+	}
+
+	if !used {
+		return ErrInvalidMFACode
+	}
+
+	uc.logger.Info(fmt.Sprintf("user %d signed in with recovery code", user.ID))
+	return nil
+}
+
+// verifyTOTP checks code against user secret and remembers matched time
+// step, so the same code can't be replayed
+func (uc *UserUseCase) verifyTOTP(user *models.User, code string) error {
+	secret, err := security.Decrypt(user.MFASecret, uc.mfaKey, mfaAdditionalData(user.ID))
+	if err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't decrypt totp secret")
+	}
+
+	step, ok := security.ValidateTOTP(secret, code, time.Now(), user.MFALastStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	user.MFALastStep = step
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't save totp state")
+	}
+
+	return nil
+}
+
+// mfaAdditionalData binds encrypted TOTP secret to user it belongs to
+func mfaAdditionalData(userID uint) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(userID))
+	return data
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b, err := security.RandomBytes(8)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		raw := hex.EncodeToString(b)
+		codes[i] = fmt.Sprintf("%s-%s-%s-%s", raw[0:4], raw[4:8], raw[8:12], raw[12:16])
+		hashes[i] = security.HashToken(raw)
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
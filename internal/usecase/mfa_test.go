@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+func TestThrottleMFA(t *testing.T) {
+	tc := newTestUseCase(t)
+	user := &models.User{Name: "user", Email: "user@example.com"}
+	user.ID = 1
+	claims := &security.Claims{UserID: user.ID, Purpose: security.PurposeMFA}
+	if _, err := tc.jwt.GenerateJWT(claims); err != nil {
+		t.Fatalf("unable to generate mfa token: %s", err)
+	}
+	client := ClientInfo{IP: "127.0.0.1"}
+	subjects := loginSubjects(user.Email, client.IP)
+	window := loginFailuresWindow.Milliseconds()
+
+	for attempt := int64(1); attempt <= mfaTokenMaxFailures; attempt++ {
+		for _, subject := range subjects {
+			tc.cache.ExpectPTTL("login_blocks:lockout:" + subject.key).SetVal(-2)
+			tc.cache.ExpectPTTL("login_blocks:delay:" + subject.key).SetVal(-2)
+		}
+
+		// Account counter stays within free attempts, since it's reset
+		// by successful password check between MFA tokens in real flow
+		for _, subject := range subjects {
+			tc.cache.CustomMatch(matchScriptKey).ExpectEvalSha("", []string{"login_failures:" + subject.key}, window).SetVal(int64(1))
+		}
+		tc.cache.CustomMatch(matchScriptKey).ExpectEvalSha("", []string{"login_failures:mfa_token:" + claims.Id}, mfaTokenTTL.Milliseconds()).SetVal(attempt)
+
+		if attempt == mfaTokenMaxFailures {
+			tc.cache.CustomMatch(matchKey).ExpectSet("revoked_tokens:"+claims.Id, 1, mfaTokenTTL+time.Millisecond).SetVal("OK")
+		}
+
+		err := tc.throttleMFA(claims, user, client, func() error {
+			return ErrInvalidMFACode
+		})
+		if err != ErrInvalidMFACode {
+			t.Fatalf("attempt %d: expected %v, got %v instead", attempt, ErrInvalidMFACode, err)
+		}
+	}
+
+	tc.expectationsMet(t)
+}
+
+func TestThrottleMFALockedAccount(t *testing.T) {
+	tc := newTestUseCase(t)
+	user := &models.User{Name: "user", Email: "user@example.com"}
+	client := ClientInfo{IP: "127.0.0.1"}
+	subjects := loginSubjects(user.Email, client.IP)
+
+	tc.cache.ExpectPTTL("login_blocks:lockout:" + subjects[0].key).SetVal(time.Minute)
+
+	err := tc.throttleMFA(&security.Claims{}, user, client, func() error {
+		t.Error("code was checked for locked account")
+		return nil
+	})
+	if !errors.Is(err, ErrAccountLocked) {
+		t.Errorf("expected %v, got %v instead", ErrAccountLocked, err)
+	}
+
+	tc.expectationsMet(t)
+}
+
+func TestDisableTOTPWrongPassword(t *testing.T) {
+	tc := newTestUseCase(t)
+	hash, err := tc.hasher.Hash("Curr3nt!Secret#9")
+	if err != nil {
+		t.Fatalf("unable to hash password: %s", err)
+	}
+	user := models.User{Name: "user", Email: "user@example.com", Password: hash, MFAEnabled: true}
+	user.ID = 1
+	claims := &security.Claims{UserID: user.ID}
+	if _, err := tc.jwt.GenerateJWT(claims); err != nil {
+		t.Fatalf("unable to generate access token: %s", err)
+	}
+	client := ClientInfo{IP: "127.0.0.1"}
+	subjects := loginSubjects(user.Email, client.IP)
+
+	tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(1).WillReturnRows(userRows(user))
+	for _, subject := range subjects {
+		tc.cache.ExpectPTTL("login_blocks:lockout:" + subject.key).SetVal(-2)
+		tc.cache.ExpectPTTL("login_blocks:delay:" + subject.key).SetVal(-2)
+	}
+	for _, subject := range subjects {
+		tc.cache.CustomMatch(matchScriptKey).ExpectEvalSha("", []string{"login_failures:" + subject.key}, loginFailuresWindow.Milliseconds()).SetVal(int64(1))
+	}
+	tc.cache.CustomMatch(matchScriptKey).ExpectEvalSha("", []string{"login_failures:mfa_token:" + claims.Id}, mfaTokenTTL.Milliseconds()).SetVal(int64(1))
+
+	err = tc.DisableTOTP(claims, "Wr0ng!Secret#9", "123456", "", client)
+	if !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("expected %v, got %v instead", ErrInvalidPassword, err)
+	}
+
+	tc.expectationsMet(t)
+}
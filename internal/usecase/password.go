@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/logger"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+const (
+	passwordResetPurpose = "password_reset"
+	passwordResetTTL     = time.Hour
+	minPepperLength      = 32
+)
+
+// newPasswordHasher creates hasher from configuration. Value of HMAC_KEY
+// environment variable is kept as pepper of hashes created before peppers
+// got IDs. Running in release mode without configured pepper is an error.
+func newPasswordHasher(conf *config.Config, logger logger.Logger) (*security.PasswordHasher, error) {
+	peppers := []security.Pepper{{Key: []byte(os.Getenv("HMAC_KEY"))}}
+
+	for _, pepperConf := range conf.Password.Peppers {
+		if pepperConf.ID == "" {
+			return nil, errors.New("password pepper id is required")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(pepperConf.Key)
+		if err != nil || len(key) < minPepperLength {
+			return nil, fmt.Errorf("password pepper %q must be base64-encoded key of at least %d bytes", pepperConf.ID, minPepperLength)
+		}
+
+		peppers = append(peppers, security.Pepper{ID: pepperConf.ID, Key: key})
+	}
+
+	if len(conf.Password.Peppers) == 0 {
+		if conf.Server.Mode == config.ReleaseMode {
+			return nil, errors.New("no password pepper configured")
+		}
+		logger.Warning("no password pepper configured, passwords are hashed with legacy HMAC_KEY pepper")
+	}
+
+	params := security.Argon2Params{
+		Memory:      conf.Password.Argon2Memory,
+		Iterations:  conf.Password.Argon2Iterations,
+		Parallelism: conf.Password.Argon2Parallelism,
+		SaltLength:  security.DefaultArgon2Params.SaltLength,
+		KeyLength:   security.DefaultArgon2Params.KeyLength,
+	}
+
+	return security.NewPasswordHasher(params, peppers, conf.Password.CurrentPepper)
+}
+
+// RequestPasswordReset emails single-use password reset link to user with
+// given email. Like RequestMagicLink, it behaves the same way for unknown
+// email.
+func (uc *UserUseCase) RequestPasswordReset(email string) {
+	user, err := uc.repo.FindUserByEmail(email)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		if err := uc.sendPasswordReset(user); err != nil {
+			uc.logger.Error(err)
+		}
+	}()
+}
+
+func (uc *UserUseCase) sendPasswordReset(user *models.User) error {
+	token, err := security.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.auth.CreateOneTimeToken(passwordResetPurpose, security.HashToken(token), user.ID, passwordResetTTL); err != nil {
+		return err
+	}
+
+	return uc.sendMail(mailer.PasswordResetKey, &mailer.PasswordResetMessage{
+		Username: user.Name,
+		Email:    user.Email,
+		Token:    token,
+	})
+}
+
+// ResetPassword sets new password for user the reset token was issued to.
+// On success every session of user is terminated and other outstanding
+// reset and sign-in links stop working.
+func (uc *UserUseCase) ResetPassword(token, password string) error {
+	hash := security.HashToken(token)
+
+	// Token is looked up without being used first, so that it's not wasted
+	// on password which doesn't meet requirements
+	userID, err := uc.auth.FindOneTimeToken(passwordResetPurpose, hash)
+	if err != nil {
+		if !errors.Is(err, repository.ErrTokenNotFound) {
+			uc.logger.Error(err)
+		}
+		return ErrInvalidResetToken
+	}
+
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrInvalidResetToken
+	}
+
+	if err := uc.checkNewPassword(password, user.Name, user.Email); err != nil {
+		return err
+	}
+
+	if err := uc.checkPasswordReuse(user, password); err != nil {
+		return err
+	}
+
+	if _, err := uc.auth.UseOneTimeToken(passwordResetPurpose, hash); err != nil {
+		if !errors.Is(err, repository.ErrTokenNotFound) {
+			uc.logger.Error(err)
+		}
+		return ErrInvalidResetToken
+	}
+
+	// Token is used before password is replaced, so it can't be used twice
+	// concurrently, and is given back if password can't be saved
+	if err := uc.replacePassword(user, password); err != nil {
+		if err := uc.auth.CreateOneTimeToken(passwordResetPurpose, hash, user.ID, passwordResetTTL); err != nil {
+			uc.logger.Error(err)
+		}
+		return err
+	}
+
+	for _, purpose := range []string{passwordResetPurpose, magicLinkPurpose} {
+		if err := uc.auth.RevokeUserOneTimeTokens(user.ID, purpose); err != nil {
+			uc.logger.Error(err)
+			return errors.New("can't revoke one-time tokens")
+		}
+	}
+
+	return uc.revokeAllTokens(user.ID)
+}
+
+// ChangePassword replaces password of authenticated user, requiring the
+// current one. User is notified about the change by email.
+func (uc *UserUseCase) ChangePassword(userID uint, currentPassword, newPassword string) error {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrUserNotFound
+	}
+
+	if !uc.verifyPassword(user, currentPassword) {
+		return ErrInvalidPassword
+	}
+
+	if err := uc.checkNewPassword(newPassword, user.Name, user.Email); err != nil {
+		return err
+	}
+
+	if err := uc.checkPasswordReuse(user, newPassword); err != nil {
+		return err
+	}
+
+	if err := uc.replacePassword(user, newPassword); err != nil {
+		return err
+	}
+
+	if err := uc.auth.RevokeUserOneTimeTokens(user.ID, passwordResetPurpose); err != nil {
+		uc.logger.Error(err)
+	}
+
+	err = uc.sendMail(mailer.PasswordChangedKey, &mailer.PasswordChangedMessage{
+		Username: user.Name,
+		Email:    user.Email,
+	})
+	if err != nil {
+		uc.logger.Error(err)
+	}
+
+	return nil
+}
+
+// setPassword hashes password with current parameters and saves it
+func (uc *UserUseCase) setPassword(user *models.User, password string) error {
+	hashedPassword, err := uc.hasher.Hash(password)
+	if err != nil {
+		uc.logger.Error(err)
+		return errors.New("unable to encrypt password")
+	}
+
+	user.Password = hashedPassword
+	user.Salt = nil
+
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't save new password")
+	}
+
+	return nil
+}
+
+// replacePassword sets new password chosen by user, moving the previous
+// one to password history
+func (uc *UserUseCase) replacePassword(user *models.User, password string) error {
+	previous := models.PasswordHistory{UserID: user.ID, Password: user.Password, Salt: user.Salt}
+
+	if err := uc.setPassword(user, password); err != nil {
+		return err
+	}
+
+	if size := uc.conf.Password.HistorySize; size > 1 {
+		if err := uc.repo.AddPasswordHistory(&previous, size-1); err != nil {
+			uc.logger.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// checkPasswordReuse rejects current password of the user and previous ones
+// kept in password history. Unlike breached passwords corpus, history which
+// can't be read rejects the password, since reuse can't be ruled out.
+func (uc *UserUseCase) checkPasswordReuse(user *models.User, password string) error {
+	size := uc.conf.Password.HistorySize
+	if size <= 0 {
+		return nil
+	}
+
+	if uc.verifyPassword(user, password) {
+		return ErrPasswordReused
+	}
+
+	if size == 1 {
+		return nil
+	}
+
+	history, err := uc.repo.FindPasswordHistory(user.ID, size-1)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrPasswordHistory
+	}
+
+	for _, entry := range history {
+		if uc.hasher.Verify(password, entry.Password, entry.Salt) {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// checkNewPassword rejects password which doesn't meet password policy or
+// is found in breached passwords corpus. Corpus errors are only logged, so
+// that broken corpus doesn't block signups.
+func (uc *UserUseCase) checkNewPassword(password string, userInputs ...string) error {
+	if violations := uc.policy.Validate(password, userInputs...); len(violations) > 0 {
+		return fmt.Errorf("%w: password %s", ErrWeakPassword, strings.Join(violations, ", "))
+	}
+
+	if uc.breach == nil {
+		return nil
+	}
+
+	breached, err := uc.breach.Breached(password)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil
+	}
+
+	if breached {
+		return ErrPasswordBreached
+	}
+
+	return nil
+}
+
+func (uc *UserUseCase) verifyPassword(user *models.User, password string) bool {
+	return uc.hasher.Verify(password, user.Password, user.Salt)
+}
+
+// rehashPassword upgrades hash of just verified password if it was produced
+// by legacy algorithm, with outdated parameters or pepper. Failure doesn't prevent
+// login, since the old hash stays valid.
+func (uc *UserUseCase) rehashPassword(user *models.User, password string) {
+	if !uc.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	if err := uc.setPassword(user, password); err != nil {
+		uc.logger.Error(err)
+	}
+}
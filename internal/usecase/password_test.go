@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/models"
+)
+
+func TestChangePasswordHistory(t *testing.T) {
+	const (
+		currentPassword = "Curr3nt!Secret#9"
+		newPassword     = "Tr0ub4dor&3xyz!"
+	)
+
+	tests := []struct {
+		Name    string
+		History []string
+		Err     error
+		Result  error
+	}{
+		{"ReusedPasswordShouldFail", []string{"0ld!Secret#One", newPassword}, nil, ErrPasswordReused},
+		{"UnavailableHistoryShouldFail", nil, errors.New("connection refused"), ErrPasswordHistory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			hash, err := tc.hasher.Hash(currentPassword)
+			if err != nil {
+				t.Fatalf("unable to hash password: %s", err)
+			}
+			user := models.User{Name: "user", Email: "user@example.com", Password: hash, Enabled: true}
+			user.ID = 1
+
+			tc.db.ExpectQuery("SELECT (.+) FROM `users`").WillReturnRows(userRows(user))
+
+			query := tc.db.ExpectQuery("SELECT (.+) FROM `password_histories`")
+			if tt.Err != nil {
+				query.WillReturnError(tt.Err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "password", "salt"})
+				for i, password := range tt.History {
+					entry, err := tc.hasher.Hash(password)
+					if err != nil {
+						t.Fatalf("unable to hash password: %s", err)
+					}
+					rows.AddRow(i+1, user.ID, entry, nil)
+				}
+				query.WillReturnRows(rows)
+			}
+
+			if err := tc.ChangePassword(user.ID, currentPassword, newPassword); !errors.Is(err, tt.Result) {
+				t.Errorf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package usecase
+
+import "sort"
+
+// rolePermissions returns sorted set of permissions granted by roles.
+// Roles missing in configuration grant nothing.
+func (uc *UserUseCase) rolePermissions(roles []string) []string {
+	set := make(map[string]bool)
+	for _, role := range roles {
+		for _, permission := range uc.roles[role] {
+			set[permission] = true
+		}
+	}
+
+	permissions := make([]string, 0, len(set))
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+
+	return permissions
+}
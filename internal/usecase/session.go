@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/response"
+	"github.com/Hickar/gin-rush/pkg/security"
+)
+
+const (
+	// sessionTouchInterval limits how often last seen time of session is
+	// saved on authenticated requests
+	sessionTouchInterval = time.Minute
+	maxUserAgentLength   = 512
+)
+
+// ClientInfo describes device request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// startSession creates session for user who just logged in and issues the
+// first token pair within it
+func (uc *UserUseCase) startSession(user *models.User, client ClientInfo) (*response.AuthUserResponse, error) {
+	id, err := security.RandomToken(16)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't generate session id")
+	}
+
+	if err := uc.sessions.CreateSession(uc.newSession(id, user.ID, client)); err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't create session")
+	}
+
+	return uc.issueTokens(user, id)
+}
+
+func (uc *UserUseCase) newSession(id string, userID uint, client ClientInfo) *models.Session {
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := time.Now()
+	return &models.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(uc.sessionTTL()),
+	}
+}
+
+// refreshSession prolongs session on token refresh. Session which is gone
+// isn't recreated, so that signing it out ends its refresh token chain.
+func (uc *UserUseCase) refreshSession(id string, userID uint, client ClientInfo) error {
+	err := uc.sessions.TouchSession(uc.newSession(id, userID, client))
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return ErrSessionNotFound
+	}
+
+	return err
+}
+
+func (uc *UserUseCase) sessionTTL() time.Duration {
+	return time.Duration(uc.conf.Server.RefreshTokenTTL) * time.Second
+}
+
+// checkSession makes sure that session access token was issued within still
+// exists, updating its last seen time from time to time
+func (uc *UserUseCase) checkSession(claims *security.Claims) error {
+	session, err := uc.sessions.FindSession(claims.SessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrTokenRevoked
+		}
+		uc.logger.Error(err)
+		return ErrInvalidToken
+	}
+
+	if session.UserID != claims.UserID {
+		return ErrTokenRevoked
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		session.LastSeenAt = time.Now()
+
+		if err := uc.sessions.TouchSession(session); err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			uc.logger.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// GetSessions lists active sessions of the user, marking the one request
+// was authenticated within
+func (uc *UserUseCase) GetSessions(userID uint, currentID string) ([]response.SessionResponse, error) {
+	sessions, err := uc.sessions.FindUserSessions(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, errors.New("can't get sessions")
+	}
+
+	resp := make([]response.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		resp[i] = response.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentID,
+		}
+	}
+
+	return resp, nil
+}
+
+// RevokeSession signs user out on the device of given session. Its access
+// tokens are rejected right away, since session no longer exists.
+func (uc *UserUseCase) RevokeSession(userID uint, id string) error {
+	session, err := uc.sessions.FindSession(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		uc.logger.Error(err)
+		return errors.New("can't find session")
+	}
+
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return uc.deleteSession(userID, id)
+}
+
+// deleteSession removes session together with its refresh token family
+func (uc *UserUseCase) deleteSession(userID uint, id string) error {
+	if err := uc.auth.RevokeRefreshFamily(id); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't revoke refresh token")
+	}
+
+	if err := uc.sessions.DeleteSession(userID, id); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't delete session")
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/models"
+)
+
+func TestRevokeSession(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Exists  bool
+		UserID  uint
+		Result  error
+		Deleted bool
+	}{
+		{"OwnSessionShouldBeRevoked", true, 1, nil, true},
+		{"SessionOfOtherUserShouldNotBeFound", true, 2, ErrSessionNotFound, false},
+		{"MissingSessionShouldNotBeFound", false, 1, ErrSessionNotFound, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			id := "abc"
+			if tt.Exists {
+				tc.sessions.CreateSession(&models.Session{ID: id, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+			}
+
+			if tt.Result == nil {
+				tc.cache.ExpectDel("refresh_families:" + id).SetVal(1)
+			}
+
+			if err := tc.RevokeSession(tt.UserID, id); !errors.Is(err, tt.Result) {
+				t.Errorf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			_, err := tc.sessions.FindSession(id)
+			if deleted := err != nil; deleted != tt.Deleted {
+				t.Errorf("unexpected session presence after revocation: %v", err)
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	client := ClientInfo{IP: "127.0.0.1", UserAgent: "test"}
+
+	t.Run("MissingSessionShouldNotBeRecreated", func(t *testing.T) {
+		tc := newTestUseCase(t)
+
+		if err := tc.refreshSession("abc", 1, client); !errors.Is(err, ErrSessionNotFound) {
+			t.Fatalf("expected %v, got %v instead", ErrSessionNotFound, err)
+		}
+
+		if _, err := tc.sessions.FindSession("abc"); err == nil {
+			t.Error("deleted session was recreated")
+		}
+	})
+
+	t.Run("ExistingSessionShouldBeProlonged", func(t *testing.T) {
+		tc := newTestUseCase(t)
+
+		created := time.Now().Add(-time.Hour)
+		tc.sessions.CreateSession(&models.Session{ID: "abc", UserID: 1, IP: "10.0.0.1", CreatedAt: created, LastSeenAt: created, ExpiresAt: created.Add(time.Hour)})
+
+		if err := tc.refreshSession("abc", 1, client); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		session, _ := tc.sessions.FindSession("abc")
+		if session.IP != client.IP || !session.LastSeenAt.After(created) || !session.ExpiresAt.After(time.Now()) {
+			t.Errorf("session wasn't prolonged: %+v", session)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/mailer"
+	"github.com/Hickar/gin-rush/internal/models"
+)
+
+// SuspendUser forbids user to log in until given time, or indefinitely if
+// it's zero, and terminates every session. Access tokens already issued are
+// rejected with ErrUserSuspended rather than as revoked ones. User is
+// notified about suspension by email.
+func (uc *UserUseCase) SuspendUser(userID, suspendedBy uint, reason string, until time.Time) error {
+	if !until.IsZero() && !until.After(time.Now()) {
+		return ErrUnprocessableEntity
+	}
+
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	user.SuspendedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	user.SuspendedUntil = sql.NullTime{Time: until, Valid: !until.IsZero()}
+	user.SuspendedBy = sql.NullInt64{Int64: int64(suspendedBy), Valid: true}
+	user.SuspensionReason = sql.NullString{String: reason, Valid: true}
+
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't suspend user")
+	}
+
+	// Marker only has to outlive access tokens issued before suspension,
+	// since no new ones are issued while it lasts
+	ttl := uc.jwt.MaxLifetime()
+	if left := time.Until(until); !until.IsZero() && left < ttl {
+		ttl = left
+	}
+
+	if err := uc.auth.MarkUserSuspended(user.ID, until, ttl); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't suspend user")
+	}
+
+	if err := uc.revokeAllTokens(user.ID); err != nil {
+		return err
+	}
+
+	err = uc.sendMail(mailer.AccountSuspendedKey, &mailer.AccountSuspendedMessage{
+		Username: user.Name,
+		Email:    user.Email,
+		Reason:   reason,
+		Until:    until,
+	})
+	if err != nil {
+		uc.logger.Error(err)
+	}
+
+	return nil
+}
+
+// UnsuspendUser allows suspended user to log in again
+func (uc *UserUseCase) UnsuspendUser(userID uint) error {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if !user.SuspendedAt.Valid {
+		return nil
+	}
+
+	return uc.liftSuspension(user)
+}
+
+func (uc *UserUseCase) liftSuspension(user *models.User) error {
+	user.SuspendedAt = sql.NullTime{}
+	user.SuspendedUntil = sql.NullTime{}
+	user.SuspendedBy = sql.NullInt64{}
+	user.SuspensionReason = sql.NullString{}
+
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't lift suspension")
+	}
+
+	if err := uc.auth.UnmarkUserSuspended(user.ID); err != nil {
+		uc.logger.Error(err)
+		return errors.New("can't lift suspension")
+	}
+
+	return nil
+}
+
+// checkSuspended rejects login of suspended user. Suspension which has
+// expired is lifted. Failure to lift it doesn't prevent login, since user
+// isn't suspended anymore either way and it's retried on the next login.
+func (uc *UserUseCase) checkSuspended(user *models.User) error {
+	if !user.SuspendedAt.Valid {
+		return nil
+	}
+
+	if !suspensionActive(user) {
+		if err := uc.liftSuspension(user); err != nil {
+			uc.logger.Error(err)
+		}
+		return nil
+	}
+
+	return &SuspendedError{Reason: user.SuspensionReason.String, Until: user.SuspendedUntil.Time}
+}
+
+// checkTokenSuspended rejects access token of suspended user
+func (uc *UserUseCase) checkTokenSuspended(userID uint) error {
+	suspended, until, err := uc.auth.UserSuspended(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return ErrInvalidToken
+	}
+
+	if suspended {
+		return &SuspendedError{Until: until}
+	}
+
+	return nil
+}
+
+func suspensionActive(user *models.User) bool {
+	if !user.SuspendedAt.Valid {
+		return false
+	}
+
+	return !user.SuspendedUntil.Valid || time.Now().Before(user.SuspendedUntil.Time)
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/models"
+)
+
+func TestSuspendUser(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Until time.Time
+	}{
+		{"TimedSuspension", time.Now().Add(24 * time.Hour)},
+		{"IndefiniteSuspension", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			user := models.User{Name: "user", Email: "user@example.com", Enabled: true}
+			user.ID = 1
+			tc.sessions.CreateSession(&models.Session{ID: "abc", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+
+			var until, marker interface{}
+			marker = int64(0)
+			if !tt.Until.IsZero() {
+				until, marker = tt.Until, tt.Until.Unix()
+			}
+
+			// Every column is saved, only suspension ones are checked
+			args := make([]driver.Value, 0, 23)
+			for i := 0; i < 18; i++ {
+				args = append(args, sqlmock.AnyArg())
+			}
+			args = append(args, sqlmock.AnyArg(), until, int64(42), "spam", 1)
+
+			tc.db.ExpectQuery("SELECT (.+) FROM `users`").WithArgs(1).WillReturnRows(userRows(user))
+			tc.db.ExpectExec(regexp.QuoteMeta("`suspended_at`=?,`suspended_until`=?,`suspended_by`=?,`suspension_reason`=? WHERE `id` = ?")).
+				WithArgs(args...).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			tc.cache.ExpectDel("users:1").SetVal(1)
+
+			// Suspension outlasts access tokens, so marker lives as long as they do
+			tc.cache.ExpectSet("users:1:suspended_until", marker, tc.jwt.MaxLifetime()).SetVal("OK")
+			tc.cache.CustomMatch(matchKey).ExpectSet("users:1:tokens_revoked_at", 0, tc.jwt.MaxLifetime()).SetVal("OK")
+			tc.cache.ExpectSMembers("users:1:refresh_families").SetVal([]string{})
+			tc.cache.ExpectDel("users:1:refresh_families").SetVal(0)
+
+			if err := tc.SuspendUser(1, 42, "spam", tt.Until); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if sessions, _ := tc.sessions.FindUserSessions(1); len(sessions) != 0 {
+				t.Errorf("sessions of user weren't terminated")
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+
+	t.Run("PastEndShouldFail", func(t *testing.T) {
+		tc := newTestUseCase(t)
+
+		if err := tc.SuspendUser(1, 42, "spam", time.Now().Add(-time.Minute)); !errors.Is(err, ErrUnprocessableEntity) {
+			t.Errorf("expected %v, got %v instead", ErrUnprocessableEntity, err)
+		}
+
+		tc.expectationsMet(t)
+	})
+}
+
+func TestCheckSuspended(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Until     time.Time
+		UpdateErr error
+		Result    error
+	}{
+		{"ActiveSuspensionShouldReject", time.Now().Add(time.Hour), nil, ErrUserSuspended},
+		{"ExpiredSuspensionShouldBeLifted", time.Now().Add(-time.Minute), nil, nil},
+		{"UnliftedExpiredSuspensionShouldAllow", time.Now().Add(-time.Minute), errors.New("connection refused"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tc := newTestUseCase(t)
+
+			user := &models.User{
+				Name:             "user",
+				Email:            "user@example.com",
+				SuspendedAt:      sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+				SuspendedUntil:   sql.NullTime{Time: tt.Until, Valid: true},
+				SuspendedBy:      sql.NullInt64{Int64: 42, Valid: true},
+				SuspensionReason: sql.NullString{String: "spam", Valid: true},
+			}
+			user.ID = 1
+
+			if tt.Result == nil {
+				exec := tc.db.ExpectExec("UPDATE `users`")
+				if tt.UpdateErr != nil {
+					exec.WillReturnError(tt.UpdateErr)
+				} else {
+					exec.WillReturnResult(sqlmock.NewResult(0, 1))
+					tc.cache.ExpectDel("users:1").SetVal(1)
+					tc.cache.ExpectDel("users:1:suspended_until").SetVal(1)
+				}
+			}
+
+			err := tc.checkSuspended(user)
+			if !errors.Is(err, tt.Result) {
+				t.Fatalf("expected %v, got %v instead", tt.Result, err)
+			}
+
+			var suspended *SuspendedError
+			if errors.As(err, &suspended) && (suspended.Reason != "spam" || !suspended.Until.Equal(tt.Until)) {
+				t.Errorf("unexpected suspension details %+v", suspended)
+			}
+
+			if tt.Result == nil && tt.UpdateErr == nil && user.SuspendedAt.Valid {
+				t.Errorf("expired suspension wasn't lifted")
+			}
+
+			tc.expectationsMet(t)
+		})
+	}
+}
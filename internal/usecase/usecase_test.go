@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Hickar/gin-rush/internal/broker"
+	"github.com/Hickar/gin-rush/internal/cache"
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/Hickar/gin-rush/internal/dbtest"
+	"github.com/Hickar/gin-rush/internal/models"
+	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/logger"
+	"github.com/go-redis/redismock/v8"
+)
+
+// sessionStoreStub keeps sessions in memory
+type sessionStoreStub struct {
+	mu       sync.Mutex
+	sessions map[string]models.Session
+}
+
+func newSessionStoreStub() *sessionStoreStub {
+	return &sessionStoreStub{sessions: map[string]models.Session{}}
+}
+
+func (s *sessionStoreStub) CreateSession(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *sessionStoreStub) FindSession(id string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (s *sessionStoreStub) FindUserSessions(userID uint) ([]models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sessions []models.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *sessionStoreStub) TouchSession(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[session.ID]; !ok {
+		return repository.ErrSessionNotFound
+	}
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *sessionStoreStub) DeleteSession(userID uint, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok && session.UserID == userID {
+		delete(s.sessions, id)
+	}
+	return nil
+}
+
+func (s *sessionStoreStub) DeleteUserSessions(userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+type testUseCase struct {
+	*UserUseCase
+	db       sqlmock.Sqlmock
+	cache    redismock.ClientMock
+	sessions *sessionStoreStub
+}
+
+// newTestUseCase creates use case with test configuration, mocked database
+// and Redis, and in-memory sessions
+func newTestUseCase(t *testing.T) *testUseCase {
+	t.Helper()
+
+	conf := config.NewConfig("../../conf/config.test.json")
+	db, dbMock := dbtest.NewMockDB(t)
+	client, cacheMock := cache.NewCacheMock()
+	br, _ := broker.NewBrokerMock()
+	l, _ := logger.NewLoggerMock()
+	sessions := newSessionStoreStub()
+
+	uc, err := NewUserUseCase(repository.NewUserRepository(db, client), repository.NewAuthRepository(client), sessions, conf, br, l)
+	if err != nil {
+		t.Fatalf("unable to create use case: %s", err)
+	}
+
+	return &testUseCase{UserUseCase: uc, db: dbMock, cache: cacheMock, sessions: sessions}
+}
+
+// expectationsMet fails test if some of database or Redis expectations
+// weren't met
+func (tc *testUseCase) expectationsMet(t *testing.T) {
+	t.Helper()
+
+	if err := tc.db.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of DB expectations were not met: %s", err)
+	}
+
+	if err := tc.cache.ExpectationsWereMet(); err != nil {
+		t.Errorf("some of Redis expectations were not met: %s", err)
+	}
+}
+
+// matchKey matches Redis command by name and key only, for commands with
+// arguments which can't be known in advance, such as TTL computed from
+// current time
+func matchKey(expected, actual []interface{}) error {
+	if len(expected) < 2 || len(actual) < 2 || expected[0] != actual[0] || expected[1] != actual[1] {
+		return errors.New("command doesn't match")
+	}
+	return nil
+}
+
+// matchCommand matches Redis command by name only, for commands with keys
+// which can't be known in advance, such as hashes of random tokens
+func matchCommand(expected, actual []interface{}) error {
+	if len(expected) == 0 || len(actual) == 0 || expected[0] != actual[0] {
+		return errors.New("command doesn't match")
+	}
+	return nil
+}
+
+// matchScriptKey matches script run by its first key only, so that tests
+// don't depend on script source
+func matchScriptKey(expected, actual []interface{}) error {
+	if len(expected) < 4 || len(actual) < 4 || expected[0] != actual[0] || expected[3] != actual[3] {
+		return errors.New("script call doesn't match")
+	}
+	return nil
+}
+
+// userRows returns row of users table with given fields set
+func userRows(user models.User) *sqlmock.Rows {
+	columns := []string{"id", "created_at", "updated_at", "deleted_at", "name", "email", "password", "enabled",
+		"confirmation_code", "confirmation_expires_at", "suspended_at", "suspended_until", "suspended_by", "suspension_reason", "mfa_enabled"}
+
+	now := time.Now()
+	return sqlmock.NewRows(columns).AddRow(user.ID, now, now, value(user.DeletedAt), user.Name, user.Email, user.Password, user.Enabled,
+		value(user.ConfirmationCode), value(user.ConfirmationExpiresAt), value(user.SuspendedAt), value(user.SuspendedUntil),
+		value(user.SuspendedBy), value(user.SuspensionReason), user.MFAEnabled)
+}
+
+func value(v driver.Valuer) driver.Value {
+	val, _ := v.Value()
+	return val
+}
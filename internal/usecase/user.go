@@ -2,33 +2,51 @@ package usecase
 
 import (
 	"database/sql"
-	"encoding/json"
+	"encoding/base64"
 	"errors"
 	"time"
 
 	"github.com/Hickar/gin-rush/internal/broker"
 	"github.com/Hickar/gin-rush/internal/config"
-	"github.com/Hickar/gin-rush/internal/mailer"
 	"github.com/Hickar/gin-rush/internal/models"
 	"github.com/Hickar/gin-rush/internal/repository"
+	"github.com/Hickar/gin-rush/pkg/breach"
 	"github.com/Hickar/gin-rush/pkg/logger"
 	"github.com/Hickar/gin-rush/pkg/request"
+	"github.com/Hickar/gin-rush/pkg/response"
 	"github.com/Hickar/gin-rush/pkg/security"
-	"github.com/Hickar/gin-rush/pkg/utils"
+	"github.com/Hickar/gin-rush/pkg/validators"
 )
 
 type UserUseCase struct {
-	repo   *repository.UserRepository
-	conf   *config.Config
-	broker broker.Broker
-	logger logger.Logger
+	repo     *repository.UserRepository
+	auth     *repository.AuthRepository
+	sessions repository.SessionRepository
+	jwt      *security.JWTManager
+	hasher   *security.PasswordHasher
+	breach   breach.Checker
+	signer   *security.TokenSigner
+	policy   validators.PasswordPolicy
+	roles    map[string][]string
+	mfaKey   []byte
+	conf     *config.Config
+	broker   broker.Broker
+	logger   logger.Logger
 }
 
-func NewUserUseCase(repo *repository.UserRepository, conf *config.Config, broker broker.Broker, logger logger.Logger) (*UserUseCase, error) {
+func NewUserUseCase(repo *repository.UserRepository, auth *repository.AuthRepository, sessions repository.SessionRepository, conf *config.Config, broker broker.Broker, logger logger.Logger) (*UserUseCase, error) {
 	if repo == nil {
 		return nil, errors.New("user repository is nil")
 	}
 
+	if auth == nil {
+		return nil, errors.New("auth repository is nil")
+	}
+
+	if sessions == nil {
+		return nil, errors.New("session repository is nil")
+	}
+
 	if conf == nil {
 		return nil, errors.New("config is nil")
 	}
@@ -41,69 +59,131 @@ func NewUserUseCase(repo *repository.UserRepository, conf *config.Config, broker
 		return nil, errors.New("logger is nil")
 	}
 
-	return &UserUseCase{repo: repo, conf: conf, broker: broker, logger: logger}, nil
+	keys, err := security.NewKeyRing(conf.Server.JWTSecret, conf.Server.JWTKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	jwt := security.NewJWTManager(keys, &conf.Server)
+
+	mfaKey, err := base64.StdEncoding.DecodeString(conf.Server.MFAEncryptKey)
+	if err != nil || len(mfaKey) != 32 {
+		return nil, errors.New("mfa encryption key must be base64-encoded 32 bytes")
+	}
+
+	switch conf.Server.EmailVerification {
+	case config.EmailVerificationBlock, config.EmailVerificationRestrict, config.EmailVerificationAllow:
+	default:
+		return nil, errors.New("unknown email verification policy")
+	}
+
+	signer, err := newTokenSigner(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := newPasswordHasher(conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := validators.NewPasswordPolicy(conf.Password.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := models.DefaultRolePermissions
+	if conf.Roles != nil {
+		roles = conf.Roles
+	}
+
+	var checker breach.Checker
+	if conf.Password.BreachedPath != "" {
+		if checker, err = breach.NewChecker(conf.Password.BreachedFormat, conf.Password.BreachedPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return &UserUseCase{repo: repo, auth: auth, sessions: sessions, jwt: jwt, hasher: hasher, breach: checker, signer: signer, policy: policy, roles: roles, mfaKey: mfaKey, conf: conf, broker: broker, logger: logger}, nil
 }
 
-func (uc *UserUseCase) CreateUser(email, name, pass string) (string, error) {
+// PasswordPolicy returns policy new passwords are checked against, so that
+// request validation uses the same configured policy
+func (uc *UserUseCase) PasswordPolicy() validators.PasswordPolicy {
+	return uc.policy
+}
+
+func (uc *UserUseCase) CreateUser(email, name, pass string, client ClientInfo) (*response.AuthUserResponse, error) {
 	var user models.User
 	if exists, _ := uc.repo.UserWithEmailExists(email); exists {
-		return "", ErrUserExists
+		return nil, ErrUserExists
+	}
+
+	if err := uc.checkNewPassword(pass, name, email); err != nil {
+		return nil, err
 	}
 
-	salt, _ := security.RandomBytes(16)
-	hashedPassword, err := security.HashPassword(pass, salt)
+	hashedPassword, err := uc.hasher.Hash(pass)
 	if err != nil {
-		return "", errors.New("unable to encrypt password")
+		uc.logger.Error(err)
+		return nil, errors.New("unable to encrypt password")
 	}
 
 	user.Name = name
 	user.Email = email
 	user.Password = hashedPassword
-	user.ConfirmationCode = utils.RandomString(30)
-	user.Salt = salt
 
 	err = uc.repo.CreateUser(&user)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, ErrUserExists
+		}
 		uc.logger.Error(err)
-		return "", errors.New("unable to create new user")
+		return nil, errors.New("unable to create new user")
 	}
 
-	token, err := security.GenerateJWT(user.ID, uc.conf.Server.JWTSecret)
-	if err != nil {
-		return "", errors.New("can't generate jwt")
+	tokens := &response.AuthUserResponse{EmailVerificationRequired: true}
+	if uc.conf.Server.EmailVerification != config.EmailVerificationBlock {
+		if tokens, err = uc.startSession(&user, client); err != nil {
+			return nil, err
+		}
 	}
 
-	msg, err := json.Marshal(&mailer.ConfirmationMessage{
-		Username: user.Name,
-		Email:    user.Email,
-		Code:     user.ConfirmationCode,
-	})
-	if err = uc.broker.Publish("mailer_ex", "mailer", "text/plain", &msg); err != nil {
-		return "", errors.New("can't publish message to broker")
+	if err := uc.sendConfirmationCode(&user); err != nil {
+		return nil, err
 	}
 
-	return token, nil
+	return tokens, nil
 }
 
-func (uc *UserUseCase) AuthorizeUser(email, pass string) (string, error) {
+// AuthorizeUser checks user credentials. Failed attempts are counted per
+// account and client IP, and too many of them make further attempts
+// rejected with RetryAfterError before password is even checked.
+func (uc *UserUseCase) AuthorizeUser(email, pass string, client ClientInfo) (*response.AuthUserResponse, error) {
+	subjects := loginSubjects(email, client.IP)
+	if err := uc.checkLoginAllowed(subjects); err != nil {
+		return nil, err
+	}
+
 	user, err := uc.repo.FindUserByEmail(email)
 	if err != nil {
 		uc.logger.Error(err)
-		return "", ErrUserNotFound
+		uc.recordLoginFailure(subjects, nil)
+		return nil, ErrUserNotFound
 	}
 
-	valid := security.VerifyPassword(pass, user.Password, user.Salt)
-	if !valid {
-		return "", ErrInvalidPassword
+	if !uc.verifyPassword(user, pass) {
+		uc.recordLoginFailure(subjects, user)
+		return nil, ErrInvalidPassword
 	}
 
-	token, err := security.GenerateJWT(user.ID, uc.conf.Server.JWTSecret)
-	if err != nil {
+	uc.rehashPassword(user, pass)
+
+	if err := uc.auth.ResetLoginFailures(subjects[0].key); err != nil {
 		uc.logger.Error(err)
-		return "", errors.New("can't generate jwt")
 	}
 
-	return token, nil
+	return uc.completeLogin(user, client)
 }
 
 func (uc *UserUseCase) UpdateUser(newUserInfo request.UpdateUserRequest, authUserID uint) error {
@@ -136,8 +216,24 @@ func (uc *UserUseCase) UpdateUser(newUserInfo request.UpdateUserRequest, authUse
 	return nil
 }
 
-func (uc *UserUseCase) GetUser(userID uint) (*models.User, error) {
-	return uc.repo.FindUserByID(userID)
+// GetUser returns public profile of user
+func (uc *UserUseCase) GetUser(userID uint) (*response.UpdateUserResponse, error) {
+	user, err := uc.repo.FindUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	resp := &response.UpdateUserResponse{
+		Name:   user.Name,
+		Bio:    user.Bio.String,
+		Avatar: user.Avatar.String,
+	}
+
+	if user.BirthDate.Valid {
+		resp.BirthDate = user.BirthDate.Time.Format("2006-01-02")
+	}
+
+	return resp, nil
 }
 
 func (uc *UserUseCase) DeleteUser(userID uint) error {
@@ -152,24 +248,16 @@ func (uc *UserUseCase) DeleteUser(userID uint) error {
 		return errors.New("can't delete user record in db")
 	}
 
-	return nil
+	return uc.revokeAllTokens(user.ID)
 }
 
-func (uc *UserUseCase) EnableUser(code string) (string, error) {
-	if len(code) != 30 {
-		return "", ErrUnprocessableEntity
-	}
-
-	user, err := uc.repo.EnableUserByCode(code)
-	if err != nil {
-		return "", err
-	}
-
-	token, err := security.GenerateJWT(user.ID, uc.conf.Server.JWTSecret)
+// EnableUser confirms email of user the confirmation code was sent to and
+// signs the user in
+func (uc *UserUseCase) EnableUser(code string, client ClientInfo) (*response.AuthUserResponse, error) {
+	user, err := uc.ConfirmEmail(code)
 	if err != nil {
-		uc.logger.Error(err)
-		return "", errors.New("unable to generate jwt")
+		return nil, err
 	}
 
-	return token, nil
+	return uc.completeLogin(user, client)
 }
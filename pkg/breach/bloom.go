@@ -0,0 +1,124 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+)
+
+var bloomMagic = [4]byte{'B', 'L', 'M', '1'}
+
+// BloomFilter is a compact probabilistic set of password hashes. It never
+// misses breached password, but may report a small share of other passwords
+// as breached too, which is acceptable for password policy.
+type BloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint32
+}
+
+// NewBloomFilter creates empty filter sized for n hashes with given false
+// positive rate
+func NewBloomFilter(n uint64, falsePositiveRate float64) *BloomFilter {
+	if n == 0 {
+		n = 1
+	}
+
+	size := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint32(math.Max(1, math.Round(float64(size)/float64(n)*math.Ln2)))
+
+	return &BloomFilter{bits: make([]uint64, (size+63)/64), size: size, hashes: hashes}
+}
+
+// LoadBloomFilter reads filter written by WriteTo from file
+func LoadBloomFilter(path string) (*BloomFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadBloomFilter(bufio.NewReader(file))
+}
+
+// ReadBloomFilter reads filter written by WriteTo
+func ReadBloomFilter(r io.Reader) (*BloomFilter, error) {
+	var header struct {
+		Magic  [4]byte
+		Size   uint64
+		Hashes uint32
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+
+	if header.Magic != bloomMagic || header.Size == 0 || header.Hashes == 0 {
+		return nil, errors.New("invalid bloom filter file")
+	}
+
+	f := &BloomFilter{bits: make([]uint64, (header.Size+63)/64), size: header.Size, hashes: header.Hashes}
+	if err := binary.Read(r, binary.LittleEndian, f.bits); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// WriteTo writes filter in binary format understood by ReadBloomFilter
+func (f *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	header := struct {
+		Magic  [4]byte
+		Size   uint64
+		Hashes uint32
+	}{bloomMagic, f.size, f.hashes}
+
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return 0, err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, f.bits); err != nil {
+		return 0, err
+	}
+
+	return int64(binary.Size(header) + binary.Size(f.bits)), nil
+}
+
+// Add adds SHA-1 digest of password to filter
+func (f *BloomFilter) Add(digest [sha1.Size]byte) {
+	f.locate(digest, func(bit uint64) bool {
+		f.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+}
+
+// Contains reports whether SHA-1 digest of password may be in filter
+func (f *BloomFilter) Contains(digest [sha1.Size]byte) bool {
+	return f.locate(digest, func(bit uint64) bool {
+		return f.bits[bit/64]&(1<<(bit%64)) != 0
+	})
+}
+
+func (f *BloomFilter) Breached(password string) (bool, error) {
+	return f.Contains(Hash(password)), nil
+}
+
+// locate calls fn with every bit of digest until fn returns false. Bits are
+// derived with double hashing from two halves of digest, which is already
+// uniformly distributed.
+func (f *BloomFilter) locate(digest [sha1.Size]byte, fn func(bit uint64) bool) bool {
+	h1 := binary.LittleEndian.Uint64(digest[0:8])
+	h2 := binary.LittleEndian.Uint64(digest[8:16]) | 1
+
+	for i := uint64(0); i < uint64(f.hashes); i++ {
+		if !fn((h1 + i*h2) % f.size) {
+			return false
+		}
+	}
+
+	return true
+}
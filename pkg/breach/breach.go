@@ -0,0 +1,52 @@
+// Package breach checks passwords against local copy of breached passwords
+// corpus published by Have I Been Pwned. Corpus is identified by uppercase
+// hex SHA-1 of password, so no network access is needed at runtime.
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Checker reports whether password is known to be breached
+type Checker interface {
+	Breached(password string) (bool, error)
+}
+
+// Corpus formats accepted by NewChecker
+const (
+	FormatRangeDir = "range_dir"
+	FormatBloom    = "bloom"
+)
+
+// NewChecker opens corpus at path in given format
+func NewChecker(format, path string) (Checker, error) {
+	switch format {
+	case FormatRangeDir:
+		return NewRangeDirChecker(path)
+	case FormatBloom:
+		return LoadBloomFilter(path)
+	default:
+		return nil, fmt.Errorf("unknown breached passwords corpus format %q", format)
+	}
+}
+
+// Hash returns SHA-1 digest of password as it is stored in the corpus
+func Hash(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// ParseHash parses hex-encoded SHA-1 digest, as found in the corpus
+func ParseHash(s string) ([sha1.Size]byte, error) {
+	var digest [sha1.Size]byte
+
+	b, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != sha1.Size {
+		return digest, fmt.Errorf("invalid SHA-1 hash %q", s)
+	}
+
+	copy(digest[:], b)
+	return digest, nil
+}
@@ -0,0 +1,88 @@
+package breach
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRangeFile(t *testing.T, dir string, passwords ...string) {
+	for _, password := range passwords {
+		digest := Hash(password)
+		hash := strings.ToUpper(hex.EncodeToString(digest[:]))
+		line := fmt.Sprintf("%s:42\r\n", hash[prefixLength:])
+
+		if err := ioutil.WriteFile(filepath.Join(dir, hash[:prefixLength]), []byte(line), 0600); err != nil {
+			t.Fatalf("unable to write range file: %s", err)
+		}
+	}
+}
+
+func TestRangeDirChecker(t *testing.T) {
+	dir := t.TempDir()
+	writeRangeFile(t, dir, "P@ssw0rd", "qwerty123")
+
+	checker, err := NewChecker(FormatRangeDir, dir)
+	if err != nil {
+		t.Fatalf("unable to open corpus: %s", err)
+	}
+
+	tests := []struct {
+		password string
+		breached bool
+	}{
+		{"P@ssw0rd", true},
+		{"qwerty123", true},
+		{"vX8#mQ2!rTz9", false},
+	}
+
+	for _, tt := range tests {
+		breached, err := checker.Breached(tt.password)
+		if err != nil || breached != tt.breached {
+			t.Errorf("password %q: expected breached %t, got %t (%v)", tt.password, tt.breached, breached, err)
+		}
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeRangeFile(t, dir, "P@ssw0rd", "qwerty123")
+
+	filter := NewBloomFilter(2, 0.001)
+	err := RangeFiles(dir, func(hash string) error {
+		digest, err := ParseHash(hash)
+		if err != nil {
+			return err
+		}
+
+		filter.Add(digest)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to read range files: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := filter.WriteTo(&buf); err != nil {
+		t.Fatalf("unable to write filter: %s", err)
+	}
+
+	loaded, err := ReadBloomFilter(&buf)
+	if err != nil {
+		t.Fatalf("unable to read filter: %s", err)
+	}
+
+	for _, password := range []string{"P@ssw0rd", "qwerty123"} {
+		if breached, _ := loaded.Breached(password); !breached {
+			t.Errorf("password %q must be reported as breached", password)
+		}
+	}
+
+	if breached, _ := loaded.Breached("vX8#mQ2!rTz9"); breached {
+		t.Error("password which is not in corpus is reported as breached")
+	}
+}
@@ -0,0 +1,109 @@
+package breach
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const prefixLength = 5
+
+// RangeDirChecker looks passwords up in directory of HIBP range files, as
+// produced by PwnedPasswordsDownloader. Each file is named by the first five
+// hex characters of SHA-1 and contains "SUFFIX:COUNT" lines with the rest of
+// hashes sharing that prefix.
+type RangeDirChecker struct {
+	dir string
+}
+
+func NewRangeDirChecker(dir string) (*RangeDirChecker, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	return &RangeDirChecker{dir: dir}, nil
+}
+
+func (c *RangeDirChecker) Breached(password string) (bool, error) {
+	digest := Hash(password)
+	hash := strings.ToUpper(hex.EncodeToString(digest[:]))
+
+	file, err := os.Open(filepath.Join(c.dir, hash[:prefixLength]))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	return findSuffix(file, hash[prefixLength:])
+}
+
+// RangeFiles calls fn with every full hash found in range files of dir
+func RangeFiles(dir string, fn func(hash string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || len(entry.Name()) != prefixLength {
+			continue
+		}
+
+		if err := rangeFile(filepath.Join(dir, entry.Name()), entry.Name(), fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rangeFile(path, prefix string, fn func(hash string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if suffix := lineHash(scanner.Text()); suffix != "" {
+			if err := fn(prefix + suffix); err != nil {
+				return err
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+func findSuffix(file *os.File, suffix string) (bool, error) {
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.EqualFold(lineHash(scanner.Text()), suffix) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// lineHash returns hash part of "HASH:COUNT" line
+func lineHash(line string) string {
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+
+	return strings.TrimSpace(line)
+}
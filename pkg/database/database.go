@@ -29,7 +29,7 @@ func NewDatabase(conf *config.DatabaseConfig, gormConf *gorm.Config) (*Database,
 }
 
 func (db *Database) FindBy(model interface{}, field string, values interface{}) error {
-	return db.Where(field + " = ?", values).First(model).Error
+	return db.Where(field+" = ?", values).First(model).Error
 }
 
 func (db *Database) Exists(model interface{}, key string, value interface{}) (bool, error) {
@@ -45,4 +45,4 @@ func (db *Database) Exists(model interface{}, key string, value interface{}) (bo
 	}
 
 	return true, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter keeps sliding window log of requests in process memory.
+// Limits are not shared between instances, so it's mostly useful as fallback.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	requests  map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{requests: make(map[string][]time.Time)}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (*Result, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now, limit.Window)
+
+	log := trimWindow(l.requests[key], now.Add(-limit.Window))
+	allowed := len(log) < limit.Requests
+	if allowed {
+		log = append(log, now)
+	}
+	l.requests[key] = log
+
+	oldest := now
+	if len(log) > 0 {
+		oldest = log[0]
+	}
+
+	return newResult(allowed, limit, len(log), oldest, now), nil
+}
+
+// sweep removes keys without requests in the last window, at most once per window
+func (l *MemoryLimiter) sweep(now time.Time, window time.Duration) {
+	if now.Sub(l.lastSweep) < window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, log := range l.requests {
+		if len(log) == 0 || !log[len(log)-1].After(now.Add(-window)) {
+			delete(l.requests, key)
+		}
+	}
+}
+
+func trimWindow(log []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(log) && !log[i].After(since) {
+		i++
+	}
+
+	return log[i:]
+}
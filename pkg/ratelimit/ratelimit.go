@@ -0,0 +1,73 @@
+// Package ratelimit implements sliding window rate limiting backed by Redis,
+// with in-process limiter as a fallback.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit allows Requests per Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result describes state of the window after request was counted
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow counts request identified by key against limit
+	Allow(ctx context.Context, key string, limit Limit) (*Result, error)
+}
+
+// fallbackLimiter uses fallback limiter whenever primary one fails
+type fallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	onError  func(error)
+}
+
+// NewFallbackLimiter returns limiter which asks primary limiter first and
+// switches to fallback one if it returns error. onError, if not nil, is
+// called with every error of primary limiter.
+func NewFallbackLimiter(primary, fallback Limiter, onError func(error)) Limiter {
+	return &fallbackLimiter{primary: primary, fallback: fallback, onError: onError}
+}
+
+func (l *fallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	res, err := l.primary.Allow(ctx, key, limit)
+	if err == nil {
+		return res, nil
+	}
+
+	if l.onError != nil {
+		l.onError(err)
+	}
+
+	return l.fallback.Allow(ctx, key, limit)
+}
+
+func newResult(allowed bool, limit Limit, count int, oldest, now time.Time) *Result {
+	res := &Result{Allowed: allowed, Limit: limit.Requests, Remaining: limit.Requests - count}
+	if res.Remaining < 0 {
+		res.Remaining = 0
+	}
+
+	res.Reset = oldest.Add(limit.Window).Sub(now)
+	if res.Reset < 0 {
+		res.Reset = 0
+	}
+
+	if !allowed {
+		res.RetryAfter = res.Reset
+	}
+
+	return res
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, Limit) (*Result, error) {
+	return nil, errors.New("redis is unavailable")
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Requests: 3, Window: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < limit.Requests; i++ {
+		res, _ := limiter.Allow(ctx, "key", limit)
+		if !res.Allowed {
+			t.Fatalf("request %d must be allowed", i+1)
+		}
+
+		if res.Remaining != limit.Requests-i-1 {
+			t.Errorf("expected %d remaining requests, got %d", limit.Requests-i-1, res.Remaining)
+		}
+	}
+
+	res, _ := limiter.Allow(ctx, "key", limit)
+	if res.Allowed {
+		t.Error("request over limit must be rejected")
+	}
+
+	if res.RetryAfter <= 0 || res.RetryAfter > limit.Window {
+		t.Errorf("unexpected retry after: %s", res.RetryAfter)
+	}
+
+	if res, _ := limiter.Allow(ctx, "other", limit); !res.Allowed {
+		t.Error("limits of different keys must be independent")
+	}
+}
+
+func TestMemoryLimiterWindow(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Requests: 1, Window: 50 * time.Millisecond}
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "key", limit)
+	if res, _ := limiter.Allow(ctx, "key", limit); res.Allowed {
+		t.Fatal("request over limit must be rejected")
+	}
+
+	time.Sleep(limit.Window)
+
+	if res, _ := limiter.Allow(ctx, "key", limit); !res.Allowed {
+		t.Error("request must be allowed once window passed")
+	}
+}
+
+func TestFallbackLimiter(t *testing.T) {
+	var reported error
+	limiter := NewFallbackLimiter(failingLimiter{}, NewMemoryLimiter(), func(err error) {
+		reported = err
+	})
+
+	res, err := limiter.Allow(context.Background(), "key", Limit{Requests: 1, Window: time.Minute})
+	if err != nil || !res.Allowed {
+		t.Fatalf("fallback limiter must be used, got %v, %v", res, err)
+	}
+
+	if reported == nil {
+		t.Error("error of primary limiter must be reported")
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript keeps timestamps of requests in sorted set, dropping
+// ones outside of the window. Request is recorded only if it's allowed.
+// Returns whether request is allowed, number of requests in window and
+// timestamp of the oldest one.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	redis.call("PEXPIRE", KEYS[1], window)
+	count = count + 1
+	allowed = 1
+end
+
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")[2] or now
+return {allowed, count, tonumber(oldest)}
+`)
+
+// RedisLimiter shares sliding window log of requests between all instances
+type RedisLimiter struct {
+	cache  *redis.Client
+	prefix string
+}
+
+func NewRedisLimiter(cache *redis.Client, prefix string) *RedisLimiter {
+	return &RedisLimiter{cache: cache, prefix: prefix}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	now := time.Now()
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+	member := strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.FormatInt(rand.Int63(), 36)
+
+	reply, err := slidingWindowScript.Run(ctx, l.cache, []string{l.prefix + key},
+		nowMs, limit.Window.Milliseconds(), limit.Requests, member).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 3 {
+		return nil, fmt.Errorf("unexpected rate limit script reply: %v", reply)
+	}
+
+	var res [3]int64
+	for i, v := range values {
+		if res[i], ok = v.(int64); !ok {
+			return nil, fmt.Errorf("unexpected rate limit script reply: %v", reply)
+		}
+	}
+
+	oldest := time.Unix(0, res[2]*int64(time.Millisecond))
+	return newResult(res[0] == 1, limit, int(res[1]), oldest, now), nil
+}
@@ -1,14 +1,16 @@
 package request
 
+import "time"
+
 type CreateUserRequest struct {
 	Name     string `json:"name" binding:"required,max=128,notblank" maxLength:"128"`
 	Email    string `json:"email" binding:"required,validemail" maxLength:"128"`
-	Password string `json:"password" binding:"required,validpassword" minLength:"6" maxLength:"64"`
+	Password string `json:"password" binding:"required,validpassword"`
 }
 
 type AuthUserRequest struct {
 	Email    string `json:"email" binding:"required,validemail" maxLength:"128"`
-	Password string `json:"password" binding:"required,validpassword" minLength:"6" maxLength:"64"`
+	Password string `json:"password" binding:"required,passwordlength"`
 }
 
 type UpdateUserRequest struct {
@@ -16,4 +18,89 @@ type UpdateUserRequest struct {
 	Bio       string `json:"bio" binding:"max=512" maxLength:"512"`
 	Avatar    string `json:"avatar"`
 	BirthDate string `json:"birth_date" binding:"validbirthdate"`
-}
\ No newline at end of file
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required,max=128" maxLength:"128"`
+}
+
+type MFACodeRequest struct {
+	Code         string `json:"code" binding:"required_without=RecoveryCode,max=6" maxLength:"6"`
+	RecoveryCode string `json:"recovery_code" binding:"required_without=Code,max=32" maxLength:"32"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	MFACodeRequest
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required,passwordlength"`
+	MFACodeRequest
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric" minLength:"6" maxLength:"6"`
+}
+
+type VerifyEmailMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required,len=6,numeric" minLength:"6" maxLength:"6"`
+}
+
+type PasswordConfirmationRequest struct {
+	Password string `json:"password" binding:"required,passwordlength"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,validemail" maxLength:"128"`
+}
+
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token" binding:"required,max=128" maxLength:"128"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,validemail" maxLength:"128"`
+}
+
+type CompletePasswordResetRequest struct {
+	Token    string `json:"token" binding:"required,max=128" maxLength:"128"`
+	Password string `json:"password" binding:"required,validpassword"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,passwordlength"`
+	NewPassword     string `json:"new_password" binding:"required,validpassword"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,validemail" maxLength:"128"`
+	Password string `json:"password" binding:"required,passwordlength"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required,max=128" maxLength:"128"`
+}
+
+type ConfirmEmailRequest struct {
+	Code string `json:"code" form:"code" binding:"required,max=128" maxLength:"128"`
+}
+
+type ResendConfirmationRequest struct {
+	Email string `json:"email" binding:"required,validemail" maxLength:"128"`
+}
+
+type ListUsersRequest struct {
+	Query   string `form:"q" binding:"max=128" maxLength:"128"`
+	Deleted bool   `form:"deleted"`
+	Page    int    `form:"page" binding:"min=0,max=10000" maximum:"10000"`
+	Limit   int    `form:"limit" binding:"min=0,max=100" maximum:"100"`
+}
+
+// SuspendUserRequest suspends user until given time, or indefinitely if
+// it's omitted
+type SuspendUserRequest struct {
+	Reason string     `json:"reason" binding:"required,max=512,notblank" maxLength:"512"`
+	Until  *time.Time `json:"until"`
+}
@@ -1,7 +1,75 @@
 package response
 
+import "time"
+
+// ErrorResponse describes why request was rejected, when status code alone
+// is not enough
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// SuspensionResponse describes why request of suspended user was rejected.
+// Until is omitted if suspension is indefinite.
+type SuspensionResponse struct {
+	Error  string     `json:"error"`
+	Reason string     `json:"reason,omitempty"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
 type AuthUserResponse struct {
-	Token string `json:"token"`
+	Token        string   `json:"token,omitempty"`
+	RefreshToken string   `json:"refresh_token,omitempty"`
+	MFARequired  bool     `json:"mfa_required,omitempty"`
+	MFAToken     string   `json:"mfa_token,omitempty"`
+	MFAMethods   []string `json:"mfa_methods,omitempty"`
+	// EmailVerificationRequired is set instead of tokens when login is
+	// blocked until email is confirmed
+	EmailVerificationRequired bool `json:"email_verification_required,omitempty"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// AdminUserResponse is full user record shown to administrators
+type AdminUserResponse struct {
+	ID               uint       `json:"id"`
+	Name             string     `json:"name"`
+	Email            string     `json:"email"`
+	PendingEmail     string     `json:"pending_email,omitempty"`
+	Bio              string     `json:"bio"`
+	Avatar           string     `json:"avatar"`
+	BirthDate        string     `json:"birth_date"`
+	Enabled          bool       `json:"enabled"`
+	MFAEnabled       bool       `json:"mfa_enabled"`
+	EmailMFAEnabled  bool       `json:"email_mfa_enabled"`
+	Roles            []string   `json:"roles,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	DeletedAt        *time.Time `json:"deleted_at"`
+	SuspendedAt      *time.Time `json:"suspended_at"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	SuspendedBy      *uint      `json:"suspended_by,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+}
+
+type UserListResponse struct {
+	Users []AdminUserResponse `json:"users"`
+	Total int64               `json:"total"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 type UpdateUserResponse struct {
@@ -9,4 +77,4 @@ type UpdateUserResponse struct {
 	Bio       string `json:"bio" binding:"max=512" maxLength:"512"`
 	Avatar    string `json:"avatar"`
 	BirthDate string `json:"birth_date" binding:"validbirthdate"`
-}
\ No newline at end of file
+}
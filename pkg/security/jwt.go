@@ -1,31 +1,155 @@
 package security
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/Hickar/gin-rush/internal/config"
 	"github.com/golang-jwt/jwt"
 )
 
+// Audience is "aud" claim, which may be encoded either as a single string
+// or as an array of strings
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+	return nil
+}
+
+// Contains reports whether audience has at least one of given values
+func (a Audience) Contains(values []string) bool {
+	for _, aud := range a {
+		for _, v := range values {
+			if aud == v {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// PurposeMFA marks short-lived token proving that user passed the first
+// authentication factor. Such token can only be exchanged for access token.
+const PurposeMFA = "mfa"
+
 type Claims struct {
-	UserID uint `json:"userID"`
+	UserID        uint     `json:"userID"`
+	SessionID     string   `json:"sid,omitempty"`
+	Purpose       string   `json:"purpose,omitempty"`
+	EmailVerified bool     `json:"email_verified"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+	Audience      Audience `json:"aud,omitempty"`
+	// IssuedAtMilli is issue time with millisecond precision, which "iat"
+	// lacks
+	IssuedAtMilli int64 `json:"iat_ms,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateJWT(userID uint, secret string) (string, error) {
-	signingKey := []byte(secret)
+// IssuedAtMillis returns issue time of token in milliseconds, falling back
+// to "iat" for tokens issued without "iat_ms"
+func (c *Claims) IssuedAtMillis() int64 {
+	if c.IssuedAtMilli != 0 {
+		return c.IssuedAtMilli
+	}
+
+	return c.IssuedAt * 1000
+}
+
+// HasPermission reports whether token grants permission
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// JWTManager issues and validates JWT signed with keys from KeyRing
+type JWTManager struct {
+	keys     *KeyRing
+	ttl      time.Duration
+	issuer   string
+	audience []string
+	leeway   time.Duration
+}
 
-	claims := &Claims{
-		UserID:         userID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * 30).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
+func NewJWTManager(keys *KeyRing, conf *config.ServerConfig) *JWTManager {
+	return &JWTManager{
+		keys:     keys,
+		ttl:      time.Duration(conf.JWTTTL) * time.Second,
+		issuer:   conf.JWTIssuer,
+		audience: conf.JWTAudience,
+		leeway:   time.Duration(conf.JWTLeeway) * time.Second,
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// MaxLifetime is the longest period token issued by GenerateJWT may be
+// accepted by ParseJWT, including clock skew leeway
+func (m *JWTManager) MaxLifetime() time.Duration {
+	return m.ttl + m.leeway
+}
 
-	ss, err := token.SignedString(signingKey)
+// AcceptedUntil returns moment after which ParseJWT rejects token with claims
+func (m *JWTManager) AcceptedUntil(claims *Claims) time.Time {
+	return time.Unix(claims.ExpiresAt, 0).Add(m.leeway)
+}
+
+func (m *JWTManager) JWKS() JWKS {
+	return m.keys.JWKS()
+}
+
+// GenerateJWT signs claims with currently active key. Registered claims are
+// filled by manager, except for "exp", which is kept if already set.
+func (m *JWTManager) GenerateJWT(claims *Claims) (string, error) {
+	now := time.Now()
+
+	signingKey, err := m.keys.SigningKey(now)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims.Id = id
+	claims.Subject = strconv.FormatUint(uint64(claims.UserID), 10)
+	claims.Issuer = m.issuer
+	claims.Audience = m.audience
+	claims.IssuedAt = now.Unix()
+	claims.IssuedAtMilli = now.UnixMilli()
+	claims.NotBefore = now.Unix()
+
+	if claims.ExpiresAt == 0 {
+		claims.ExpiresAt = now.Add(m.ttl).Unix()
+	}
+
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	if signingKey.ID != "" {
+		token.Header["kid"] = signingKey.ID
+	}
+
+	ss, err := token.SignedString(signingKey.Private)
 	if err != nil {
 		return "", err
 	}
@@ -33,20 +157,61 @@ func GenerateJWT(userID uint, secret string) (string, error) {
 	return ss, nil
 }
 
-func ParseJWT(tokenString, secret string) (*Claims, error) {
-	signingKey := []byte(secret)
+// ParseJWT verifies token signature with the key referenced by "kid" header.
+// Token is accepted only if it's signed with exactly the algorithm of that key.
+func (m *JWTManager) ParseJWT(tokenString string) (*Claims, error) {
+	parser := &jwt.Parser{ValidMethods: m.keys.Algorithms(), SkipClaimsValidation: true}
+
+	token, err := parser.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return signingKey, nil
+		key, err := m.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+		}
+
+		return key.Public, nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid JWT token")
 	}
 
-	return nil, errors.New("invalid JWT token")
-}
\ No newline at end of file
+	if err := m.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (m *JWTManager) validateClaims(claims *Claims) error {
+	now := time.Now()
+	leeway := int64(m.leeway / time.Second)
+
+	if !claims.VerifyExpiresAt(now.Unix()-leeway, true) {
+		return errors.New("token is expired")
+	}
+
+	if !claims.VerifyNotBefore(now.Unix()+leeway, false) || !claims.VerifyIssuedAt(now.Unix()+leeway, false) {
+		return errors.New("token is not valid yet")
+	}
+
+	if m.issuer != "" && !claims.VerifyIssuer(m.issuer, true) {
+		return errors.New("token has invalid issuer")
+	}
+
+	if len(m.audience) > 0 && !claims.Audience.Contains(m.audience) {
+		return errors.New("token has invalid audience")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/config"
+)
+
+func TestJWTManager(t *testing.T) {
+	keys, _ := NewKeyRing("secret", nil)
+	conf := config.ServerConfig{
+		JWTTTL:      60,
+		JWTIssuer:   "https://issuer.io",
+		JWTAudience: []string{"gateway", "billing"},
+		JWTLeeway:   30,
+	}
+	manager := NewJWTManager(keys, &conf)
+
+	foreignConf := conf
+	foreignConf.JWTIssuer = "https://other.io"
+	foreign := NewJWTManager(keys, &foreignConf)
+
+	otherAudienceConf := conf
+	otherAudienceConf.JWTAudience = []string{"reports"}
+	otherAudience := NewJWTManager(keys, &otherAudienceConf)
+
+	tests := []struct {
+		name      string
+		generator *JWTManager
+		claims    Claims
+		shouldErr bool
+	}{
+		{"Valid", manager, Claims{UserID: 1}, false},
+		{"ExpiredWithinLeeway", manager, Claims{UserID: 1}, false},
+		{"Expired", manager, Claims{UserID: 1}, true},
+		{"WrongIssuer", foreign, Claims{UserID: 1}, true},
+		{"WrongAudience", otherAudience, Claims{UserID: 1}, true},
+	}
+
+	tests[1].claims.ExpiresAt = time.Now().Add(-10 * time.Second).Unix()
+	tests[2].claims.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.generator.GenerateJWT(&tt.claims)
+			if err != nil {
+				t.Fatalf("unable to generate token: %s", err)
+			}
+
+			claims, err := manager.ParseJWT(token)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("unexpected parse result: %v", err)
+			}
+
+			if err == nil && (claims.Subject != "1" || claims.Issuer != conf.JWTIssuer) {
+				t.Errorf("unexpected claims: %+v", claims)
+			}
+
+			if err == nil && claims.IssuedAtMillis()/1000 != claims.IssuedAt {
+				t.Errorf("issue time in milliseconds %d doesn't match %d", claims.IssuedAtMillis(), claims.IssuedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrUnknownKey = errors.New("unknown JWT signing key")
+
+// SigningKey is a single JWT key identified by "kid" header. Keys without
+// private part are used only for verification.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	Private    interface{}
+	Public     interface{}
+	ActiveFrom time.Time
+	RetireAt   time.Time
+}
+
+func (k *SigningKey) activeAt(t time.Time) bool {
+	return k.Private != nil && !k.ActiveFrom.After(t) && !k.retiredAt(t)
+}
+
+func (k *SigningKey) retiredAt(t time.Time) bool {
+	return !k.RetireAt.IsZero() && !k.RetireAt.After(t)
+}
+
+// KeyRing holds keys used for signing and verifying JWT.
+//
+// Keys are rotated on schedule: token is signed by the key with the latest
+// active_from moment which already passed, while every not yet retired key
+// stays valid for verification and is published in JWKS. New key should be
+// added with active_from far enough in future for JWKS consumers to pick it
+// up, and old one retired no earlier than access token lifetime after that.
+type KeyRing struct {
+	keys []*SigningKey
+}
+
+// NewKeyRing loads keys listed in configuration. Non-empty secret is added
+// as HS256 key without ID; when no other keys are configured it is used for
+// signing, otherwise it stays verification-only to let already issued tokens
+// expire after migration to asymmetric keys.
+func NewKeyRing(secret string, confs []config.JWTKeyConfig) (*KeyRing, error) {
+	ring := &KeyRing{}
+
+	if secret != "" {
+		key := &SigningKey{Method: jwt.SigningMethodHS256, Public: []byte(secret)}
+		if len(confs) == 0 {
+			key.Private = []byte(secret)
+		}
+		ring.keys = append(ring.keys, key)
+	}
+
+	for _, conf := range confs {
+		key, err := loadSigningKey(conf)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load JWT key %q: %w", conf.ID, err)
+		}
+
+		if _, err := ring.find(key.ID); err == nil {
+			return nil, fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+
+		ring.keys = append(ring.keys, key)
+	}
+
+	if len(ring.keys) == 0 {
+		return nil, errors.New("no JWT signing keys configured")
+	}
+
+	return ring, nil
+}
+
+// SigningKey returns key which should be used for signing at given moment
+func (r *KeyRing) SigningKey(t time.Time) (*SigningKey, error) {
+	var current *SigningKey
+
+	for _, key := range r.keys {
+		if !key.activeAt(t) {
+			continue
+		}
+
+		if current == nil || key.ActiveFrom.After(current.ActiveFrom) {
+			current = key
+		}
+	}
+
+	if current == nil {
+		return nil, errors.New("no active JWT signing key")
+	}
+
+	return current, nil
+}
+
+// VerificationKey returns not retired key with given ID
+func (r *KeyRing) VerificationKey(id string) (*SigningKey, error) {
+	key, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.retiredAt(time.Now()) {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+// Algorithms lists signing methods of all keys in ring
+func (r *KeyRing) Algorithms() []string {
+	var algs []string
+	seen := make(map[string]bool)
+
+	for _, key := range r.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+
+	return algs
+}
+
+func (r *KeyRing) find(id string) (*SigningKey, error) {
+	for _, key := range r.keys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func loadSigningKey(conf config.JWTKeyConfig) (*SigningKey, error) {
+	if conf.ID == "" {
+		return nil, errors.New("key id is required")
+	}
+
+	key := &SigningKey{ID: conf.ID}
+
+	var err error
+	if conf.ActiveFrom != "" {
+		if key.ActiveFrom, err = time.Parse(time.RFC3339, conf.ActiveFrom); err != nil {
+			return nil, fmt.Errorf("invalid active_from: %w", err)
+		}
+	}
+
+	if conf.RetireAt != "" {
+		if key.RetireAt, err = time.Parse(time.RFC3339, conf.RetireAt); err != nil {
+			return nil, fmt.Errorf("invalid retire_at: %w", err)
+		}
+	}
+
+	var privatePEM, publicPEM []byte
+	if conf.PrivateKeyPath != "" {
+		if privatePEM, err = ioutil.ReadFile(conf.PrivateKeyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.PublicKeyPath != "" {
+		if publicPEM, err = ioutil.ReadFile(conf.PublicKeyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if privatePEM == nil && publicPEM == nil {
+		return nil, errors.New("either private or public key path is required")
+	}
+
+	switch conf.Algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		key.Method = jwt.SigningMethodRS256
+		err = parseRSAKey(key, privatePEM, publicPEM)
+	case jwt.SigningMethodES256.Alg():
+		key.Method = jwt.SigningMethodES256
+		err = parseECKey(key, privatePEM, publicPEM)
+	case jwt.SigningMethodEdDSA.Alg():
+		key.Method = jwt.SigningMethodEdDSA
+		err = parseEdKey(key, privatePEM, publicPEM)
+	default:
+		err = fmt.Errorf("unsupported algorithm %q", conf.Algorithm)
+	}
+
+	return key, err
+}
+
+func parseRSAKey(key *SigningKey, privatePEM, publicPEM []byte) error {
+	if privatePEM != nil {
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return err
+		}
+		key.Private, key.Public = private, &private.PublicKey
+	}
+
+	if publicPEM != nil {
+		public, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return err
+		}
+		key.Public = public
+	}
+
+	return nil
+}
+
+func parseECKey(key *SigningKey, privatePEM, publicPEM []byte) error {
+	var public *ecdsa.PublicKey
+
+	if privatePEM != nil {
+		private, err := jwt.ParseECPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return err
+		}
+		key.Private, public = private, &private.PublicKey
+	}
+
+	if publicPEM != nil {
+		var err error
+		if public, err = jwt.ParseECPublicKeyFromPEM(publicPEM); err != nil {
+			return err
+		}
+	}
+
+	if public.Curve != elliptic.P256() {
+		return errors.New("ES256 requires P-256 curve key")
+	}
+
+	key.Public = public
+	return nil
+}
+
+func parseEdKey(key *SigningKey, privatePEM, publicPEM []byte) error {
+	if privatePEM != nil {
+		parsed, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return err
+		}
+
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return errors.New("EdDSA requires Ed25519 key")
+		}
+		key.Private, key.Public = private, private.Public()
+	}
+
+	if publicPEM != nil {
+		public, err := jwt.ParseEdPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return err
+		}
+		key.Public = public
+	}
+
+	return nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public parts of asymmetric keys which are not retired yet
+func (r *KeyRing) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	now := time.Now()
+	enc := base64.RawURLEncoding
+
+	for _, key := range r.keys {
+		if key.retiredAt(now) {
+			continue
+		}
+
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = enc.EncodeToString(public.N.Bytes())
+			jwk.E = enc.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = public.Curve.Params().Name
+			jwk.X = enc.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = enc.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = enc.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
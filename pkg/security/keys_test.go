@@ -0,0 +1,108 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Hickar/gin-rush/internal/config"
+	"github.com/golang-jwt/jwt"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("unable to write key file: %s", err)
+	}
+
+	return path
+}
+
+func TestKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edPrivate)
+	edPath := writePEM(t, dir, "ed.pem", "PRIVATE KEY", edDER)
+
+	ecPrivate, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalECPrivateKey(ecPrivate)
+	ecPath := writePEM(t, dir, "ec.pem", "EC PRIVATE KEY", ecDER)
+	ecPublicDER, _ := x509.MarshalPKIXPublicKey(&ecPrivate.PublicKey)
+
+	now := time.Now()
+	keys, err := NewKeyRing("", []config.JWTKeyConfig{
+		{ID: "old", Algorithm: "EdDSA", PrivateKeyPath: edPath, ActiveFrom: now.Add(-time.Hour).Format(time.RFC3339)},
+		{ID: "new", Algorithm: "ES256", PrivateKeyPath: ecPath, ActiveFrom: now.Add(time.Hour).Format(time.RFC3339)},
+	})
+	if err != nil {
+		t.Fatalf("unable to create key ring: %s", err)
+	}
+
+	t.Run("ScheduledRotation", func(t *testing.T) {
+		if key, _ := keys.SigningKey(now); key.ID != "old" {
+			t.Errorf("expected key %q to be active, got %q", "old", key.ID)
+		}
+
+		if key, _ := keys.SigningKey(now.Add(2 * time.Hour)); key.ID != "new" {
+			t.Errorf("expected key %q to be active, got %q", "new", key.ID)
+		}
+	})
+
+	manager := NewJWTManager(keys, &config.ServerConfig{JWTTTL: 60})
+
+	t.Run("SignAndParse", func(t *testing.T) {
+		token, err := manager.GenerateJWT(&Claims{UserID: 42, SessionID: "session"})
+		if err != nil {
+			t.Fatalf("unable to generate token: %s", err)
+		}
+
+		claims, err := manager.ParseJWT(token)
+		if err != nil {
+			t.Fatalf("unable to parse token: %s", err)
+		}
+
+		if claims.UserID != 42 || claims.Id == "" {
+			t.Errorf("unexpected claims: %+v", claims)
+		}
+	})
+
+	t.Run("AlgorithmConfusion", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1})
+		token.Header["kid"] = "new"
+
+		signed, _ := token.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecPublicDER}))
+		if _, err := manager.ParseJWT(signed); err == nil {
+			t.Error("token signed with HS256 by public key must be rejected")
+		}
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		secretKeys, _ := NewKeyRing("secret", nil)
+		token, _ := NewJWTManager(secretKeys, &config.ServerConfig{JWTTTL: 60}).GenerateJWT(&Claims{UserID: 1})
+
+		if _, err := manager.ParseJWT(token); err == nil {
+			t.Error("token signed with unknown key must be rejected")
+		}
+	})
+
+	t.Run("JWKS", func(t *testing.T) {
+		set := keys.JWKS()
+		if len(set.Keys) != 2 {
+			t.Fatalf("expected 2 keys in set, got %d", len(set.Keys))
+		}
+
+		if set.Keys[0].Kty != "OKP" || set.Keys[1].Kty != "EC" || set.Keys[1].Crv != "P-256" {
+			t.Errorf("unexpected key set: %+v", set)
+		}
+	})
+}
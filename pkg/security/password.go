@@ -5,13 +5,19 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
-	"os"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/scrypt"
 )
 
 // https://gist.github.com/Zenithar/6f650560fe710133e24d
 
+// Parameters of legacy scrypt hashes, which were stored as raw key with salt
+// in a separate column
 const (
 	scryptN      = 16384
 	scryptR      = 8
@@ -19,6 +25,8 @@ const (
 	scryptKeyLen = 32
 )
 
+var ErrInvalidHash = errors.New("invalid password hash format")
+
 func hmacSha256(in, key []byte) ([]byte, error) {
 	mac := hmac.New(sha256.New, key)
 	_, err := mac.Write(in)
@@ -40,18 +48,207 @@ func RandomBytes(count int) ([]byte, error) {
 	return salt, err
 }
 
-func HashPassword(in string, salt []byte) ([]byte, error) {
-	peppered, _ := hmacSha256([]byte(in), []byte(os.Getenv("HMAC_KEY")))
+// Argon2Params are cost parameters of argon2id hash
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow RFC 9106 recommendation for memory constrained
+// environments
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Pepper is a secret key mixed into every password before hashing. Unlike
+// salt, it's never stored in database.
+type Pepper struct {
+	ID  string
+	Key []byte
+}
+
+// PasswordHasher hashes passwords with argon2id, encoding result in PHC
+// string format, e.g. "$argon2id$v=19$m=65536,t=3,p=2,keyid=k1$<salt>$<hash>".
+// Algorithm, its parameters and ID of pepper are stored in the hash itself,
+// so all of them can be changed without invalidating existing hashes.
+type PasswordHasher struct {
+	params  Argon2Params
+	peppers map[string][]byte
+	current string
+}
+
+// NewPasswordHasher creates hasher which peppers new hashes with pepper of
+// current ID. Pepper with empty ID is used for hashes without key ID, which
+// were produced before peppers got versioned.
+func NewPasswordHasher(params Argon2Params, peppers []Pepper, current string) (*PasswordHasher, error) {
+	h := &PasswordHasher{params: params, peppers: make(map[string][]byte), current: current}
+
+	for _, pepper := range peppers {
+		if strings.ContainsAny(pepper.ID, "$,=") {
+			return nil, fmt.Errorf("invalid pepper id %q", pepper.ID)
+		}
+
+		if _, ok := h.peppers[pepper.ID]; ok {
+			return nil, fmt.Errorf("duplicate pepper id %q", pepper.ID)
+		}
+
+		h.peppers[pepper.ID] = pepper.Key
+	}
+
+	if _, ok := h.peppers[current]; !ok {
+		return nil, fmt.Errorf("unknown current pepper id %q", current)
+	}
+
+	return h, nil
+}
 
-	cur, err := encScrypt(peppered, salt)
+// Hash returns PHC-encoded argon2id hash of password with random salt
+func (h *PasswordHasher) Hash(password string) ([]byte, error) {
+	salt, err := RandomBytes(int(h.params.SaltLength))
 	if err != nil {
 		return nil, err
 	}
 
-	return cur, err
+	key := argon2.IDKey(h.peppered(password, h.current), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", h.params.Memory, h.params.Iterations, h.params.Parallelism)
+	if h.current != "" {
+		params += ",keyid=" + h.current
+	}
+
+	encoded := fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, params,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	return []byte(encoded), nil
+}
+
+// Verify checks password against encoded hash. Hashes which are not in PHC
+// format are treated as legacy scrypt keys with salt stored separately.
+func (h *PasswordHasher) Verify(password string, encoded, legacySalt []byte) bool {
+	if !isPHC(encoded) {
+		key, err := encScrypt(h.peppered(password, ""), legacySalt)
+		if err != nil {
+			return false
+		}
+		return subtle.ConstantTimeCompare(key, encoded) == 1
+	}
+
+	hash, err := decodeArgon2(encoded)
+	if err != nil {
+		return false
+	}
+
+	if _, ok := h.peppers[hash.pepperID]; !ok {
+		return false
+	}
+
+	params := hash.params
+	actual := argon2.IDKey(h.peppered(password, hash.pepperID), hash.salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(actual, hash.key) == 1
+}
+
+// NeedsRehash reports whether hash was produced by another algorithm, with
+// parameters different from current ones or with outdated pepper
+func (h *PasswordHasher) NeedsRehash(encoded []byte) bool {
+	if !isPHC(encoded) {
+		return true
+	}
+
+	hash, err := decodeArgon2(encoded)
+	if err != nil {
+		return true
+	}
+
+	return hash.params != h.params || hash.pepperID != h.current
+}
+
+func (h *PasswordHasher) peppered(password, pepperID string) []byte {
+	peppered, _ := hmacSha256([]byte(password), h.peppers[pepperID])
+	return peppered
+}
+
+// isPHC tells encoded hash from legacy raw scrypt key, which has fixed
+// length and may start with any byte
+func isPHC(encoded []byte) bool {
+	return len(encoded) != scryptKeyLen
+}
+
+type argon2Hash struct {
+	params   Argon2Params
+	pepperID string
+	salt     []byte
+	key      []byte
+}
+
+func decodeArgon2(encoded []byte) (*argon2Hash, error) {
+	parts := strings.Split(string(encoded), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrInvalidHash
+	}
+
+	hash := &argon2Hash{}
+
+	for _, param := range strings.Split(parts[3], ",") {
+		name, value, ok := cutParam(param)
+		if !ok {
+			return nil, ErrInvalidHash
+		}
+
+		var err error
+		switch name {
+		case "m":
+			_, err = fmt.Sscanf(value, "%d", &hash.params.Memory)
+		case "t":
+			_, err = fmt.Sscanf(value, "%d", &hash.params.Iterations)
+		case "p":
+			_, err = fmt.Sscanf(value, "%d", &hash.params.Parallelism)
+		case "keyid":
+			hash.pepperID = value
+		default:
+			err = ErrInvalidHash
+		}
+
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+	}
+
+	if hash.params.Memory == 0 || hash.params.Iterations == 0 || hash.params.Parallelism == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	var err error
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrInvalidHash
+	}
+
+	if hash.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, ErrInvalidHash
+	}
+
+	hash.params.SaltLength = uint32(len(hash.salt))
+	hash.params.KeyLength = uint32(len(hash.key))
+
+	return hash, nil
 }
 
-func VerifyPassword(plain string, hashed, salt []byte) bool {
-	h, _ := HashPassword(plain, salt)
-	return subtle.ConstantTimeCompare(h, hashed) == 1
-}
\ No newline at end of file
+func cutParam(param string) (string, string, bool) {
+	i := strings.IndexByte(param, '=')
+	if i < 0 {
+		return "", "", false
+	}
+
+	return param[:i], param[i+1:], true
+}
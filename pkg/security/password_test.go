@@ -0,0 +1,100 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newTestHasher(t *testing.T, params Argon2Params, peppers []Pepper, current string) *PasswordHasher {
+	hasher, err := NewPasswordHasher(params, peppers, current)
+	if err != nil {
+		t.Fatalf("unable to create password hasher: %s", err)
+	}
+
+	return hasher
+}
+
+func TestPasswordHasher(t *testing.T) {
+	peppers := []Pepper{{ID: "k1", Key: []byte("pepper")}}
+	hasher := newTestHasher(t, testArgon2Params, peppers, "k1")
+
+	hash, err := hasher.Hash("Password123")
+	if err != nil {
+		t.Fatalf("unable to hash password: %s", err)
+	}
+
+	if !strings.HasPrefix(string(hash), "$argon2id$v=19$m=1024,t=1,p=1,keyid=k1$") {
+		t.Errorf("unexpected hash format: %s", hash)
+	}
+
+	if !hasher.Verify("Password123", hash, nil) {
+		t.Error("valid password must be accepted")
+	}
+
+	if hasher.Verify("Password124", hash, nil) {
+		t.Error("invalid password must be rejected")
+	}
+
+	if hasher.NeedsRehash(hash) {
+		t.Error("hash with current parameters doesn't need rehash")
+	}
+
+	stronger := newTestHasher(t, Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, peppers, "k1")
+	if !stronger.NeedsRehash(hash) {
+		t.Error("hash with outdated parameters needs rehash")
+	}
+
+	if !stronger.Verify("Password123", hash, nil) {
+		t.Error("hash with outdated parameters must still be verified")
+	}
+}
+
+func TestPasswordHasherPepperRotation(t *testing.T) {
+	old := newTestHasher(t, testArgon2Params, []Pepper{{ID: "k1", Key: []byte("old")}}, "k1")
+	hash, _ := old.Hash("Password123")
+
+	rotated := newTestHasher(t, testArgon2Params, []Pepper{{ID: "k1", Key: []byte("old")}, {ID: "k2", Key: []byte("new")}}, "k2")
+
+	if !rotated.Verify("Password123", hash, nil) {
+		t.Error("hash with previous pepper must still be verified")
+	}
+
+	if !rotated.NeedsRehash(hash) {
+		t.Error("hash with previous pepper needs rehash")
+	}
+
+	removed := newTestHasher(t, testArgon2Params, []Pepper{{ID: "k2", Key: []byte("new")}}, "k2")
+	if removed.Verify("Password123", hash, nil) {
+		t.Error("hash with unknown pepper must be rejected")
+	}
+
+	if _, err := NewPasswordHasher(testArgon2Params, nil, "k3"); err == nil {
+		t.Error("unknown current pepper must be rejected")
+	}
+}
+
+func TestPasswordHasherLegacy(t *testing.T) {
+	hasher := newTestHasher(t, testArgon2Params, []Pepper{{Key: []byte("legacy")}, {ID: "k1", Key: []byte("pepper")}}, "k1")
+
+	salt, _ := RandomBytes(16)
+	peppered, _ := hmacSha256([]byte("Password123"), []byte("legacy"))
+	legacy, _ := encScrypt(peppered, salt)
+
+	if !hasher.Verify("Password123", legacy, salt) {
+		t.Error("valid password must be accepted for legacy hash")
+	}
+
+	if hasher.Verify("Password124", legacy, salt) {
+		t.Error("invalid password must be rejected for legacy hash")
+	}
+
+	if !hasher.NeedsRehash(legacy) {
+		t.Error("legacy hash needs rehash")
+	}
+
+	if hasher.Verify("Password123", []byte("$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5"), nil) {
+		t.Error("hash with invalid parameters must be rejected")
+	}
+}
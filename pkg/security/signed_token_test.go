@@ -0,0 +1,78 @@
+package security
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenSigner(t *testing.T) {
+	signer, err := NewTokenSigner([]byte(strings.Repeat("k", 32)))
+	if err != nil {
+		t.Fatalf("unable to create signer: %s", err)
+	}
+
+	email := "dummy@email.io"
+	resolve := func(userID uint) (string, error) {
+		if userID != 42 {
+			return "", errors.New("user not found")
+		}
+		return email, nil
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		token := signer.Sign("confirmation", 42, email, time.Hour)
+
+		userID, err := signer.Verify(token, "confirmation", resolve)
+		if err != nil || userID != 42 {
+			t.Errorf("expected user 42, got %d with error %v", userID, err)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		token := signer.Sign("confirmation", 42, email, -time.Minute)
+
+		if _, err := signer.Verify(token, "confirmation", resolve); !errors.Is(err, ErrSignedTokenExpired) {
+			t.Errorf("expected expired token error, got %v", err)
+		}
+	})
+
+	t.Run("OtherPurpose", func(t *testing.T) {
+		token := signer.Sign("password_reset", 42, email, time.Hour)
+
+		if _, err := signer.Verify(token, "confirmation", resolve); !errors.Is(err, ErrInvalidSignedToken) {
+			t.Errorf("expected invalid token error, got %v", err)
+		}
+	})
+
+	t.Run("BindingChanged", func(t *testing.T) {
+		token := signer.Sign("confirmation", 42, "old@email.io", time.Hour)
+
+		if _, err := signer.Verify(token, "confirmation", resolve); !errors.Is(err, ErrInvalidSignedToken) {
+			t.Errorf("expected invalid token error, got %v", err)
+		}
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		token := signer.Sign("confirmation", 43, email, time.Hour)
+		forged := signer.Sign("confirmation", 42, email, time.Hour)
+		token = forged[:strings.IndexByte(forged, '.')] + token[strings.IndexByte(token, '.'):]
+
+		if _, err := signer.Verify(token, "confirmation", resolve); !errors.Is(err, ErrInvalidSignedToken) {
+			t.Errorf("expected invalid token error, got %v", err)
+		}
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, token := range []string{"", "abc", "a.b.c", "!!.!!"} {
+			if _, err := signer.Verify(token, "confirmation", resolve); !errors.Is(err, ErrInvalidSignedToken) {
+				t.Errorf("expected invalid token error for %q, got %v", token, err)
+			}
+		}
+	})
+
+	if _, err := NewTokenSigner([]byte("short")); err == nil {
+		t.Error("short key must be rejected")
+	}
+}
@@ -0,0 +1,52 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+)
+
+// RandomToken returns url-safe opaque token built from count random bytes
+func RandomToken(count int) (string, error) {
+	b, err := RandomBytes(count)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns hex-encoded SHA-256 digest of token, suitable for storing
+// high-entropy tokens at rest
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyedHash returns hex-encoded HMAC-SHA256 of value. Unlike HashToken it's
+// suitable for low-entropy values like numeric codes, which could otherwise
+// be recovered by brute force.
+func KeyedHash(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RandomDigits returns string of count uniformly distributed decimal digits
+func RandomDigits(count int) (string, error) {
+	b := make([]byte, count)
+
+	for i := range b {
+		num, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+
+		b[i] = byte('0' + num.Int64())
+	}
+
+	return string(b), nil
+}
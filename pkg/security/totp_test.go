@@ -0,0 +1,56 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// Test vectors from RFC 6238, truncated to 6 digits
+	secret := []byte("12345678901234567890")
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, v := range vectors {
+		if code := TOTPCode(secret, TOTPStep(time.Unix(v.unix, 0))); code != v.code {
+			t.Errorf("expected code %s at %d, got %s", v.code, v.unix, code)
+		}
+	}
+
+	now := time.Unix(1111111109, 0)
+
+	t.Run("AcceptsSkew", func(t *testing.T) {
+		code := TOTPCode(secret, TOTPStep(now)-1)
+		if _, ok := ValidateTOTP(secret, code, now, 0); !ok {
+			t.Error("code from previous step should be accepted")
+		}
+	})
+
+	t.Run("RejectsReplay", func(t *testing.T) {
+		code := TOTPCode(secret, TOTPStep(now))
+		step, ok := ValidateTOTP(secret, code, now, 0)
+		if !ok {
+			t.Fatal("valid code was rejected")
+		}
+
+		if _, ok := ValidateTOTP(secret, code, now, step); ok {
+			t.Error("already used code should be rejected")
+		}
+	})
+
+	t.Run("URI", func(t *testing.T) {
+		uri := TOTPURI("Gin-Rush", "dummy@email.io", secret)
+		if !strings.HasPrefix(uri, "otpauth://totp/Gin-Rush:dummy@email.io?") || !strings.Contains(uri, "secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ") {
+			t.Errorf("unexpected key URI: %s", uri)
+		}
+	})
+}
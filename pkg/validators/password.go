@@ -0,0 +1,221 @@
+package validators
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Hickar/gin-rush/internal/config"
+)
+
+// Character classes which password policy may require
+const (
+	ClassUpper  = "upper"
+	ClassLower  = "lower"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// Sizes of alphabets used for password entropy estimation. Letters outside
+// of ASCII are counted as a single alphabet of arbitrary size.
+var classAlphabets = map[string]float64{
+	ClassUpper:  26,
+	ClassLower:  26,
+	ClassDigit:  10,
+	ClassSymbol: 33,
+	"other":     100,
+}
+
+const minBannedWordLength = 3
+
+// PasswordPolicy describes requirements for new passwords
+type PasswordPolicy struct {
+	MinLength       int
+	MaxLength       int
+	RequiredClasses []string
+	// MinEntropy is the lowest acceptable strength score in bits, see
+	// PasswordEntropy. Zero disables the check.
+	MinEntropy  float64
+	BannedWords []string
+}
+
+// DefaultPasswordPolicy is checked by ValidPassword and fills settings
+// omitted from configuration
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:       6,
+	MaxLength:       64,
+	RequiredClasses: []string{ClassUpper, ClassLower, ClassDigit, ClassSymbol},
+}
+
+// NewPasswordPolicy builds policy from configuration, filling omitted
+// settings from DefaultPasswordPolicy. Empty, but not omitted, list of
+// required classes means that no particular class is required.
+func NewPasswordPolicy(conf config.PasswordPolicyConfig) (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy
+	policy.MinEntropy = conf.MinEntropy
+	policy.BannedWords = conf.BannedWords
+
+	if conf.MinLength > 0 {
+		policy.MinLength = conf.MinLength
+	}
+
+	if conf.MaxLength > 0 {
+		policy.MaxLength = conf.MaxLength
+	}
+
+	if policy.MinLength > policy.MaxLength {
+		return policy, fmt.Errorf("password min length %d exceeds max length %d", policy.MinLength, policy.MaxLength)
+	}
+
+	if conf.RequiredClasses != nil {
+		for _, class := range conf.RequiredClasses {
+			if _, ok := classAlphabets[class]; !ok || class == "other" {
+				return policy, fmt.Errorf("unknown password character class %q", class)
+			}
+		}
+		policy.RequiredClasses = conf.RequiredClasses
+	}
+
+	return policy, nil
+}
+
+// Validate returns list of requirements password doesn't meet. User inputs,
+// such as name or email, may not be used as part of password.
+func (p PasswordPolicy) Validate(password string, userInputs ...string) []string {
+	var violations []string
+
+	if IsBlank(password) {
+		return []string{"must not be blank"}
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength || length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be %d to %d characters long", p.MinLength, p.MaxLength))
+	}
+
+	classes := passwordClasses(password)
+	for _, class := range p.RequiredClasses {
+		if !classes[class] {
+			violations = append(violations, "must contain "+classDescription(class))
+		}
+	}
+
+	if p.MinEntropy > 0 && PasswordEntropy(password) < p.MinEntropy {
+		violations = append(violations, "is too easy to guess")
+	}
+
+	lower := strings.ToLower(password)
+	for _, word := range p.BannedWords {
+		if len(word) >= minBannedWordLength && strings.Contains(lower, strings.ToLower(word)) {
+			violations = append(violations, fmt.Sprintf("must not contain %q", word))
+		}
+	}
+
+	for _, word := range inputWords(userInputs) {
+		if strings.Contains(lower, word) {
+			violations = append(violations, "must not contain your name or email")
+			break
+		}
+	}
+
+	return violations
+}
+
+// Description lists requirements of policy in human readable form
+func (p PasswordPolicy) Description() string {
+	description := fmt.Sprintf("must be %d to %d characters long", p.MinLength, p.MaxLength)
+
+	if len(p.RequiredClasses) > 0 {
+		var classes []string
+		for _, class := range p.RequiredClasses {
+			classes = append(classes, classDescription(class))
+		}
+		description += " and contain " + strings.Join(classes, ", ")
+	}
+
+	if p.MinEntropy > 0 {
+		description += fmt.Sprintf(", with strength of at least %.0f bits", p.MinEntropy)
+	}
+
+	return description
+}
+
+// PasswordEntropy estimates password strength in bits as length multiplied
+// by log2 of the size of alphabet made of all character classes it uses.
+// Repeated characters are counted once, so that "aaaaaaaa" is not strong.
+func PasswordEntropy(password string) float64 {
+	var alphabet float64
+	for class := range passwordClasses(password) {
+		alphabet += classAlphabets[class]
+	}
+
+	if alphabet == 0 {
+		return 0
+	}
+
+	unique := make(map[rune]bool)
+	for _, c := range password {
+		unique[c] = true
+	}
+
+	return float64(len(unique)) * math.Log2(alphabet)
+}
+
+func passwordClasses(password string) map[string]bool {
+	classes := make(map[string]bool)
+
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			classes[ClassUpper] = true
+		case unicode.IsDigit(c):
+			classes[ClassDigit] = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			classes[ClassSymbol] = true
+		case unicode.IsLetter(c):
+			classes[ClassLower] = true
+			if c > unicode.MaxASCII {
+				classes["other"] = true
+			}
+		}
+	}
+
+	return classes
+}
+
+func classDescription(class string) string {
+	switch class {
+	case ClassUpper:
+		return "uppercase letter"
+	case ClassLower:
+		return "lowercase letter"
+	case ClassDigit:
+		return "digit"
+	default:
+		return "symbol"
+	}
+}
+
+// inputWords splits user inputs into lowercase words long enough to be
+// checked, e.g. "John Doe" and "jdoe@mail.io" into "john", "doe" and "jdoe"
+func inputWords(inputs []string) []string {
+	var words []string
+
+	for _, input := range inputs {
+		if i := strings.IndexByte(input, '@'); i >= 0 {
+			input = input[:i]
+		}
+
+		for _, word := range strings.FieldsFunc(strings.ToLower(input), func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+		}) {
+			if len(word) >= minBannedWordLength {
+				words = append(words, word)
+			}
+		}
+	}
+
+	return words
+}
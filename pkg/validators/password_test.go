@@ -0,0 +1,61 @@
+package validators
+
+import (
+	"testing"
+
+	"github.com/Hickar/gin-rush/internal/config"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy, err := NewPasswordPolicy(config.PasswordPolicyConfig{
+		MinLength:   8,
+		MinEntropy:  40,
+		BannedWords: []string{"qwerty"},
+	})
+	if err != nil {
+		t.Fatalf("unable to create password policy: %s", err)
+	}
+
+	tests := []struct {
+		Name       string
+		Password   string
+		UserInputs []string
+		Violations int
+	}{
+		{"Valid", "Pass/w0rd-Horse", nil, 0},
+		{"TooShort", "Pa/w0rd", nil, 1},
+		{"MissingClasses", "password-horse", nil, 2},
+		{"LowEntropy", "Aa1!Aa1!Aa1!", nil, 1},
+		{"BannedWord", "Qwerty/w0rd-Horse", nil, 1},
+		{"UserName", "Pass/w0rd-Johnny", []string{"Johnny Doe", "jd@mail.io"}, 1},
+		{"EmailLocalPart", "Pass/w0rd-Jdoe", []string{"John", "jdoe@mail.io"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			violations := policy.Validate(tt.Password, tt.UserInputs...)
+			if len(violations) != tt.Violations {
+				t.Errorf("expected %d violations, got %v", tt.Violations, violations)
+			}
+		})
+	}
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	policy, err := NewPasswordPolicy(config.PasswordPolicyConfig{RequiredClasses: []string{}})
+	if err != nil {
+		t.Fatalf("unable to create password policy: %s", err)
+	}
+
+	if len(policy.RequiredClasses) != 0 || policy.MinLength != DefaultPasswordPolicy.MinLength {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+
+	if _, err := NewPasswordPolicy(config.PasswordPolicyConfig{RequiredClasses: []string{"emoji"}}); err == nil {
+		t.Error("unknown character class must be rejected")
+	}
+
+	if _, err := NewPasswordPolicy(config.PasswordPolicyConfig{MinLength: 10, MaxLength: 8}); err == nil {
+		t.Error("min length exceeding max length must be rejected")
+	}
+}
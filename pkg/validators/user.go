@@ -4,7 +4,7 @@ import (
 	"net/mail"
 	"strings"
 	"time"
-	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -16,33 +16,28 @@ var ValidEmail validator.Func = func(fl validator.FieldLevel) bool {
 	return err == nil
 }
 
-var ValidPassword validator.Func = func(fl validator.FieldLevel) bool {
-	var (
-		hasUpper bool
-		hasLower bool
-		hasDigit bool
-		hasSymbol bool
-	)
-	field := fl.Field().Interface().(string)
+// ValidPassword checks password against default password policy
+var ValidPassword = PasswordValidator(DefaultPasswordPolicy)
 
-	if len(field) < 6 || len(field) > 64 {
-		return false
+// PasswordValidator returns validator checking password against policy
+func PasswordValidator(policy PasswordPolicy) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		field := fl.Field().Interface().(string)
+		return len(policy.Validate(field)) == 0
 	}
+}
 
-	for _, c := range field {
-		switch {
-		case unicode.IsUpper(c):
-			hasUpper = true
-		case unicode.IsDigit(c):
-			hasDigit = true
-		case unicode.IsPunct(c) || unicode.IsSymbol(c):
-			hasSymbol = true
-		case unicode.IsLetter(c):
-			hasLower = true
-		}
-	}
+// PasswordLength checks that password isn't longer than allowed by default
+// password policy
+var PasswordLength = PasswordLengthValidator(DefaultPasswordPolicy)
 
-	return hasUpper && hasLower && hasDigit && hasSymbol && !IsBlank(field)
+// PasswordLengthValidator returns validator checking only maximum length of
+// policy, for passwords which may have been set under previous policy
+func PasswordLengthValidator(policy PasswordPolicy) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		field := fl.Field().Interface().(string)
+		return utf8.RuneCountInString(field) <= policy.MaxLength
+	}
 }
 
 var NotBlank validator.Func = func(fl validator.FieldLevel) bool {
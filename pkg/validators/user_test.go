@@ -1,6 +1,7 @@
 package validators
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Hickar/gin-rush/pkg/request"
@@ -17,6 +18,7 @@ func TestUserValidators(t *testing.T) {
 	v.RegisterValidation("notblank", NotBlank)
 	v.RegisterValidation("validemail", ValidEmail)
 	v.RegisterValidation("validpassword", ValidPassword)
+	v.RegisterValidation("passwordlength", PasswordLength)
 	v.RegisterValidation("validbirthdate", ValidBirthDate)
 
 	v.Struct(request.CreateUserRequest{Name: "someUser", Email: "invalid.email", Password: "Pass/w0rd"})
@@ -67,13 +69,25 @@ func TestUserValidators(t *testing.T) {
 			"InvalidPasswordShouldFail",
 			request.CreateUserRequest{Name: "someUser", Email: "dummy@email.io", Password: "v"},
 			true,
-			"Password must be length of 8, contain one uppercase character, symbol and digit",
+			"Password must be 6 to 64 characters long and contain uppercase letter, lowercase letter, digit and symbol",
 		},
 		{
 			"InvalidPasswordShouldPass",
 			request.CreateUserRequest{Name: "someUser", Email: "dummy@email.io", Password: "Pass/w0rd"},
 			false,
-			"Password must be length of 8, contain one uppercase character, symbol and digit",
+			"Password meets default password policy",
+		},
+		{
+			"LongPasswordShouldFail",
+			request.AuthUserRequest{Email: "dummy@email.io", Password: strings.Repeat("p", 65)},
+			true,
+			"Password is longer than allowed by default password policy",
+		},
+		{
+			"LongPasswordShouldPass",
+			request.AuthUserRequest{Email: "dummy@email.io", Password: "pass"},
+			false,
+			"Password set under previous policy is only checked for length",
 		},
 		{
 			"InvalidBirthdateShouldFail",